@@ -0,0 +1,56 @@
+package cert4now
+
+import (
+	"context"
+	"crypto/tls"
+	"runtime"
+	"sync"
+)
+
+// Spec is the options of a certificate generated by GenerateBatch.
+type Spec struct {
+	Options []Option
+}
+
+// BatchResult is the result of generating a certificate of Spec.
+type BatchResult struct {
+	Certificate tls.Certificate
+	Err         error
+}
+
+// GenerateBatch generates the certificates of specs concurrently, then returns the results in the order of specs.
+// concurrency is the number of the certificates generated at once, runtime.NumCPU() if not positive.
+// The specs not finished before ctx is done result in the error of ctx.
+func GenerateBatch(ctx context.Context, specs []Spec, concurrency int) []BatchResult {
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	results := make([]BatchResult, len(specs))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < concurrency && n < len(specs); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					results[i].Err = err
+					continue
+				}
+				results[i].Certificate, results[i].Err = GenerateContext(ctx, specs[i].Options...)
+			}
+		}()
+	}
+
+	for i := range specs {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
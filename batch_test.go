@@ -0,0 +1,57 @@
+package cert4now_test
+
+import (
+	"context"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func TestGenerateBatch(t *testing.T) {
+	ca := generateCA(t, cert4now.CommonName("Batch CA"), cert4now.ECDSA(elliptic.P256()))
+
+	specs := make([]cert4now.Spec, 50)
+	for i := range specs {
+		specs[i].Options = []cert4now.Option{
+			cert4now.Authority(ca),
+			cert4now.ECDSA(elliptic.P256()),
+			cert4now.Names(fmt.Sprintf("node%d.example.com", i)),
+		}
+	}
+	specs[7].Options = append(specs[7].Options, cert4now.RSA(512))
+
+	results := cert4now.GenerateBatch(context.Background(), specs, 8)
+	if len(results) != len(specs) {
+		t.Fatalf("want %d results, got %d", len(specs), len(results))
+	}
+	for i, r := range results {
+		if i == 7 {
+			var keyErr *cert4now.UnsupportedKeyError
+			if !errors.As(r.Err, &keyErr) {
+				t.Errorf("[%d] want UnsupportedKeyError, got %v", i, r.Err)
+			}
+			continue
+		}
+		if r.Err != nil {
+			t.Errorf("[%d] %v", i, r.Err)
+			continue
+		}
+		if want, got := fmt.Sprintf("node%d.example.com", i), parseLeaf(t, r.Certificate).DNSNames[0]; want != got {
+			t.Errorf("[%d] want %s, got %s", i, want, got)
+		}
+	}
+}
+
+func TestGenerateBatch_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := cert4now.GenerateBatch(ctx, make([]cert4now.Spec, 10), 0)
+	for i, r := range results {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("[%d] want context.Canceled, got %v", i, r.Err)
+		}
+	}
+}
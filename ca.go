@@ -0,0 +1,378 @@
+package cert4now
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Files in the directory of CA.
+const (
+	CACertFile   = "ca.crt"      // the certificate followed by its chain in PEM
+	CAKeyFile    = "ca.key"      // the private key in PKCS#8, encrypted if the password is given
+	CASerialFile = "serial"      // the serial number to issue next in hex
+	CAIndexFile  = "index.jsonl" // the issued certificates in JSON lines
+	CARevokeFile = "revoked.jsonl"
+	CACRLFile    = "crl.pem"
+	CAConfigFile = "config.json"
+)
+
+// CAConfig is the configuration of CA stored in config.json.
+type CAConfig struct {
+	// Lifetime is the default validity period of the issued certificates, such as 90d or 1y.
+	Lifetime string `json:"lifetime"`
+
+	// CRLLifetime is the period until the next update of the CRL, such as 7d.
+	CRLLifetime string `json:"crlLifetime"`
+}
+
+var defaultCAConfig = CAConfig{
+	Lifetime:    "90d",
+	CRLLifetime: "7d",
+}
+
+// RevokedCertificate is a certificate revoked by CA.
+type RevokedCertificate struct {
+	SerialNumber string    `json:"serial"`
+	RevokedAt    time.Time `json:"revokedAt"`
+	Reason       int       `json:"reason,omitempty"`
+}
+
+// CA is a certificate authority persisted in a directory.
+// CA survives process restarts, and issues certificates with monotonically increasing serial numbers.
+type CA struct {
+	dir    string
+	cert   tls.Certificate
+	config CAConfig
+	store  *FileStore
+
+	mu sync.Mutex
+}
+
+// ErrCAExists represents the directory already has a CA.
+var ErrCAExists = errors.New("CA already exists")
+
+// CreateCA generates the certificate of a new CA, then stores it into dir.
+// The private key is encrypted with password unless password is empty.
+// The certificate is a self signed root CA valid for 10 years unless options tell otherwise,
+// for example Authority(root) makes it an intermediate CA.
+func CreateCA(dir string, password []byte, options ...Option) (*CA, error) {
+	if _, err := os.Stat(filepath.Join(dir, CACertFile)); err == nil {
+		return nil, ErrCAExists
+	}
+
+	defaults := []Option{
+		CommonName("cert4now CA"),
+		AddDate(10, 0, 0),
+		KeyUsage(x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign),
+		ExtKeyUsage(),
+		IsCA(true),
+	}
+	cert, err := Generate(append(defaults, options...)...)
+	if err != nil {
+		return nil, err
+	}
+	return InitCA(dir, password, cert)
+}
+
+// InitCA stores the certificate of an existing CA into dir.
+func InitCA(dir string, password []byte, cert tls.Certificate) (*CA, error) {
+	if _, err := os.Stat(filepath.Join(dir, CACertFile)); err == nil {
+		return nil, ErrCAExists
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	var options []WriteOption
+	if len(password) > 0 {
+		options = append(options, Encrypt(password))
+	}
+	if err := WritePrivateKeyFile(filepath.Join(dir, CAKeyFile), cert, 0600, options...); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := WriteCertificateChain(&buf, cert); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, CACertFile), buf.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+
+	config, err := json.MarshalIndent(defaultCAConfig, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, CAConfigFile), append(config, '\n'), 0644); err != nil {
+		return nil, err
+	}
+
+	ca, err := OpenCA(dir, password)
+	if err != nil {
+		return nil, err
+	}
+	if err := ca.writeCRL(); err != nil {
+		return nil, err
+	}
+	return ca, nil
+}
+
+// OpenCA opens the CA stored in dir.
+// The files other than the certificate and the private key are optional.
+func OpenCA(dir string, password []byte) (*CA, error) {
+	ca := &CA{dir: dir, config: defaultCAConfig}
+	ca.store = NewFileStore(ca.path(CAIndexFile))
+
+	p, err := os.ReadFile(ca.path(CACertFile))
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var block *pem.Block
+		block, p = pem.Decode(p)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			ca.cert.Certificate = append(ca.cert.Certificate, block.Bytes)
+		}
+	}
+	if len(ca.cert.Certificate) == 0 {
+		return nil, fmt.Errorf("%s: no certificate found", ca.path(CACertFile))
+	}
+	ca.cert.Leaf, err = x509.ParseCertificate(ca.cert.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+
+	p, err = os.ReadFile(ca.path(CAKeyFile))
+	if err != nil {
+		return nil, err
+	}
+	key, err := decodePrivateKey(p, password)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(ca.cert.Leaf.PublicKey) {
+		return nil, errors.New("private key does not match the certificate of CA")
+	}
+	ca.cert.PrivateKey = key
+
+	p, err = os.ReadFile(ca.path(CAConfigFile))
+	if err == nil {
+		err = json.Unmarshal(p, &ca.config)
+	} else if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ca, nil
+}
+
+func (ca *CA) path(name string) string {
+	return filepath.Join(ca.dir, name)
+}
+
+// Dir returns the directory of ca.
+func (ca *CA) Dir() string {
+	return ca.dir
+}
+
+// Certificate returns the certificate of ca, usable with Authority.
+func (ca *CA) Certificate() tls.Certificate {
+	return ca.cert
+}
+
+// Config returns the configuration of ca.
+func (ca *CA) Config() CAConfig {
+	return ca.config
+}
+
+// Store returns the inventory of the certificates issued by ca.
+func (ca *CA) Store() Store {
+	return ca.store
+}
+
+// Issue generates a certificate signed by ca, then records it into the index.
+// The serial number is always the next of the last one issued by ca,
+// and the validity is CAConfig.Lifetime from now unless options tell otherwise.
+func (ca *CA) Issue(options ...Option) (tls.Certificate, error) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+
+	lifetime, err := parsePeriod(ca.config.Lifetime)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	now := time.Now()
+	defaults := []Option{NotBefore(now), lifetime.option(now)}
+	options = append(defaults, options...)
+	// The serial number of the caller would break the sequence of ca.
+	options = append(options, SerialNumber(nil), UseSerialPolicy(SequentialSerialFile(ca.path(CASerialFile))), Authority(ca.cert), Inventory(ca.store))
+	cert, err := Generate(options...)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return cert, nil
+}
+
+// Issued returns the certificates issued by ca in the order of issuance.
+func (ca *CA) Issued() ([]IssuedCertificate, error) {
+	return ca.store.Find(context.Background(), Query{})
+}
+
+// Revoked returns the certificates revoked by ca.
+func (ca *CA) Revoked() ([]RevokedCertificate, error) {
+	var revoked []RevokedCertificate
+	err := readJSONLines(ca.path(CARevokeFile), func(p []byte) error {
+		var v RevokedCertificate
+		if err := json.Unmarshal(p, &v); err != nil {
+			return err
+		}
+		revoked = append(revoked, v)
+		return nil
+	})
+	return revoked, err
+}
+
+// ErrNotIssued represents the certificate of the serial number is not issued by CA.
+var ErrNotIssued = errors.New("certificate of the serial number is not issued by CA")
+
+// ErrAlreadyRevoked represents the certificate of the serial number is already revoked by CA.
+var ErrAlreadyRevoked = errors.New("certificate of the serial number is already revoked")
+
+// ErrInvalidReason represents the reason is not a CRLReason of RFC 5280.
+var ErrInvalidReason = errors.New("reason is not a CRLReason of RFC 5280")
+
+// Revoke revokes the certificate of serial, then updates the CRL.
+// The reason is the CRLReason of RFC 5280, written into the CRL unless unspecified, that is 0.
+// It returns ErrNotIssued if the certificate of serial is not found in the index,
+// and ErrAlreadyRevoked if the certificate is revoked already.
+func (ca *CA) Revoke(serial *big.Int, reason int) error {
+	// 7 is not used in RFC 5280.
+	if reason < 0 || reason > 10 || reason == 7 {
+		return ErrInvalidReason
+	}
+
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+
+	issued, err := ca.store.Find(context.Background(), Query{SerialNumber: serial.Text(16)})
+	if err != nil {
+		return err
+	}
+	if len(issued) == 0 {
+		return ErrNotIssued
+	}
+	revoked, err := ca.Revoked()
+	if err != nil {
+		return err
+	}
+	for _, v := range revoked {
+		if v.SerialNumber == serial.Text(16) {
+			return ErrAlreadyRevoked
+		}
+	}
+
+	err = appendJSONLine(ca.path(CARevokeFile), RevokedCertificate{
+		SerialNumber: serial.Text(16),
+		RevokedAt:    time.Now().UTC(),
+		Reason:       reason,
+	})
+	if err != nil {
+		return err
+	}
+	return ca.writeCRL()
+}
+
+// oidExtensionReasonCode is the OID of the CRL entry extension of the reason code.
+var oidExtensionReasonCode = asn1.ObjectIdentifier{2, 5, 29, 21}
+
+// CRL returns the certificate revocation list of ca in DER.
+func (ca *CA) CRL() ([]byte, error) {
+	revoked, err := ca.Revoked()
+	if err != nil {
+		return nil, err
+	}
+	lifetime, err := parsePeriod(ca.config.CRLLifetime)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	list := &x509.RevocationList{
+		Number:     big.NewInt(now.Unix()),
+		ThisUpdate: now,
+		NextUpdate: now.Add(lifetime.from(now)),
+	}
+	for _, v := range revoked {
+		serial, ok := new(big.Int).SetString(v.SerialNumber, 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid serial number %q", v.SerialNumber)
+		}
+		entry := pkix.RevokedCertificate{
+			SerialNumber:   serial,
+			RevocationTime: v.RevokedAt,
+		}
+		if v.Reason != 0 {
+			reason, err := asn1.Marshal(asn1.Enumerated(v.Reason))
+			if err != nil {
+				return nil, err
+			}
+			entry.Extensions = []pkix.Extension{{Id: oidExtensionReasonCode, Value: reason}}
+		}
+		list.RevokedCertificates = append(list.RevokedCertificates, entry)
+	}
+	return x509.CreateRevocationList(rand.Reader, list, ca.cert.Leaf, ca.cert.PrivateKey.(crypto.Signer))
+}
+
+func (ca *CA) writeCRL() error {
+	der, err := ca.CRL()
+	if err != nil {
+		return err
+	}
+	p := pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+	return writeFileAtomic(ca.path(CACRLFile), p, 0644)
+}
+
+// writeFileAtomic writes the file of filename through a temporary file,
+// so that the file never becomes partially written.
+func writeFileAtomic(filename string, p []byte, perm fs.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(p); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, perm); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
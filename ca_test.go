@@ -0,0 +1,107 @@
+package cert4now_test
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func TestCA(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ca")
+	password := []byte("secret")
+
+	ca, err := cert4now.CreateCA(dir, password, cert4now.CommonName("Test CA"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.example.com", "b.example.com"} {
+		if _, err := ca.Issue(cert4now.Names(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := cert4now.CreateCA(dir, password); err != cert4now.ErrCAExists {
+		t.Fatalf("want ErrCAExists, got %v", err)
+	}
+	if _, err := cert4now.OpenCA(dir, []byte("wrong")); err != cert4now.ErrIncorrectPassword {
+		t.Fatalf("want ErrIncorrectPassword, got %v", err)
+	}
+
+	ca, err = cert4now.OpenCA(dir, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := ca.Issue(cert4now.Names("c.example.com"), cert4now.SerialNumber(big.NewInt(999)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parseLeaf(t, cert).SerialNumber.Cmp(big.NewInt(3)) != 0 {
+		t.Fatalf("want serial 3, got %v", parseLeaf(t, cert).SerialNumber)
+	}
+	if err := parseLeaf(t, cert).CheckSignatureFrom(ca.Certificate().Leaf); err != nil {
+		t.Fatal(err)
+	}
+
+	issued, err := ca.Issued()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issued) != 3 || issued[2].SerialNumber != "3" || issued[2].DNSNames[0] != "c.example.com" {
+		t.Fatalf("unexpected index %+v", issued)
+	}
+
+	if err := ca.Revoke(big.NewInt(2), 1); err != nil {
+		t.Fatal(err)
+	}
+	p, err := os.ReadFile(filepath.Join(dir, cert4now.CACRLFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(p)
+	if block == nil {
+		t.Fatal("no CRL found")
+	}
+	crl, err := x509.ParseDERCRL(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ca.Certificate().Leaf.CheckCRLSignature(crl); err != nil {
+		t.Fatal(err)
+	}
+	revoked := crl.TBSCertList.RevokedCertificates
+	if len(revoked) != 1 || revoked[0].SerialNumber.Int64() != 2 {
+		t.Fatalf("unexpected revoked certificates %+v", revoked)
+	}
+	var reason asn1.Enumerated
+	if exts := revoked[0].Extensions; len(exts) != 1 || !exts[0].Id.Equal(asn1.ObjectIdentifier{2, 5, 29, 21}) {
+		t.Fatalf("no reason code in %+v", exts)
+	} else if _, err := asn1.Unmarshal(exts[0].Value, &reason); err != nil || reason != 1 {
+		t.Fatalf("want reason 1, got %v %v", reason, err)
+	}
+
+	if err := ca.Revoke(big.NewInt(42), 0); !errors.Is(err, cert4now.ErrNotIssued) {
+		t.Fatalf("want ErrNotIssued, got %v", err)
+	}
+	if err := ca.Revoke(big.NewInt(2), 1); !errors.Is(err, cert4now.ErrAlreadyRevoked) {
+		t.Fatalf("want ErrAlreadyRevoked, got %v", err)
+	}
+	for _, reason := range []int{-1, 7, 11} {
+		if err := ca.Revoke(big.NewInt(3), reason); !errors.Is(err, cert4now.ErrInvalidReason) {
+			t.Fatalf("reason %d: want ErrInvalidReason, got %v", reason, err)
+		}
+	}
+	revokedList, err := ca.Revoked()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revokedList) != 1 {
+		t.Fatalf("want 1 revoked, got %+v", revokedList)
+	}
+}
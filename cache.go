@@ -0,0 +1,215 @@
+package cert4now
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache keeps the generated certificates in a directory, then Generate reuses them while still valid.
+// It is meant for test suites generating the same certificates every run.
+type Cache struct {
+	dir string
+}
+
+// NewCache returns Cache keeping the certificates in dir.
+// The directory is created on the first certificate stored.
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// DefaultCache returns Cache keeping the certificates in the directory cert4now in os.UserCacheDir,
+// that is $XDG_CACHE_HOME/cert4now on Linux.
+func DefaultCache() (*Cache, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewCache(filepath.Join(dir, "cert4now")), nil
+}
+
+// Dir returns the directory of c.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Clear removes all the certificates in c.
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.dir)
+}
+
+// UseCache returns an option of reusing the certificate generated by the same options before.
+// The options are identified by the subject, the names, the type of the key, the authority,
+// the usages and the lifetime, but not by NotBefore of the time of the generation.
+// The certificate is reused until a half of the lifetime passes.
+// The options of recording into the inventory, UseSerialRegistry and CertificateRequest disable the cache.
+// The certificate reused is linted again only in the strict mode of linting.
+func UseCache(c *Cache) Option {
+	return func(p *param) {
+		p.cache = c
+	}
+}
+
+// cacheWindow is the tolerance to take NotBefore as the time of the generation.
+const cacheWindow = time.Minute
+
+// cacheKey is the canonical form of the parameters identifying a certificate in Cache.
+type cacheKey struct {
+	Version      int
+	Subject      string
+	RawSubject   []byte
+	SerialNumber string
+	DNSNames     []string
+	Emails       []string
+	IPAddresses  []string
+	URIs         []string
+	KeyType      string
+	PublicKey    []byte
+	Authority    []byte
+
+	SubjectKeyID    []byte
+	ExtraExtensions []pkix.Extension
+
+	KeyUsage              x509.KeyUsage
+	KeyUsageSet           bool
+	ExtKeyUsage           []x509.ExtKeyUsage
+	UnknownExtKeyUsage    []asn1.ObjectIdentifier
+	BasicConstraintsValid bool
+	IsCA                  bool
+	MaxPathLen            int
+	MaxPathLenZero        bool
+
+	SignatureAlgorithm x509.SignatureAlgorithm
+	SignatureHash      uint
+	RSAPSS             bool
+
+	// NotBefore and NotAfter are given unless relative to the time of the generation.
+	NotBefore time.Time
+	NotAfter  time.Time
+	Lifetime  time.Duration
+}
+
+// cacheKeyOf returns the key of the certificate in the cache before p is filled with the defaults,
+// and whether NotBefore is the time of the generation.
+func (p *param) cacheKeyOf(now time.Time) (key string, relative bool, err error) {
+	k := cacheKey{
+		Version:               1,
+		DNSNames:              p.dnsNames,
+		Emails:                p.emailAddresses,
+		KeyType:               p.keyType.String(),
+		KeyUsage:              p.keyUsage,
+		KeyUsageSet:           p.keyUsageSet,
+		ExtKeyUsage:           p.extKeyUsage,
+		UnknownExtKeyUsage:    p.unknownExtKeyUsage,
+		SubjectKeyID:          p.subjectKeyID,
+		ExtraExtensions:       p.extraExtensions,
+		BasicConstraintsValid: p.basicConstraintsValid,
+		IsCA:                  p.isCA,
+		MaxPathLen:            p.maxPathLen,
+		MaxPathLenZero:        p.maxPathLenZero,
+		SignatureAlgorithm:    p.signatureAlgorithm,
+		SignatureHash:         uint(p.signatureHash),
+		RSAPSS:                p.rsaPSS,
+		Lifetime:              p.lifetime,
+	}
+	if p.subject != nil {
+		k.Subject = p.subject.String()
+		k.RawSubject = p.rawSubject
+	}
+	if p.serialNumber != nil {
+		k.SerialNumber = p.serialNumber.Text(16)
+	}
+	for _, ip := range p.ipAddresses {
+		k.IPAddresses = append(k.IPAddresses, ip.String())
+	}
+	for _, uri := range p.uris {
+		k.URIs = append(k.URIs, uri.String())
+	}
+	if p.authority != nil {
+		sum := sha256.Sum256(p.authority.Raw)
+		k.Authority = sum[:]
+	}
+
+	pub := p.publicKey
+	if pub == nil && p.fixedKey {
+		signer, err := p.genSigner()
+		if err != nil {
+			return "", false, err
+		}
+		pub = signer.Public()
+	}
+	if pub != nil {
+		k.PublicKey, err = x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return "", false, err
+		}
+	}
+
+	relative = p.notBefore.IsZero() || p.notBefore.After(now.Add(-cacheWindow)) && p.notBefore.Before(now.Add(cacheWindow))
+	switch {
+	case !relative:
+		k.NotBefore, k.NotAfter = p.notBefore.UTC(), p.notAfter.UTC()
+	case p.notBefore.IsZero():
+		// NotAfter given alone is absolute.
+		k.NotAfter = p.notAfter.UTC()
+	case !p.notAfter.IsZero():
+		k.Lifetime = p.notAfter.Sub(p.notBefore).Round(time.Hour)
+	}
+
+	b, err := json.Marshal(k)
+	if err != nil {
+		return "", false, err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), relative, nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".pem")
+}
+
+// get returns the certificate of key in c if exists and still valid at now.
+func (c *Cache) get(key string, relative bool, now time.Time) (tls.Certificate, bool) {
+	p, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return tls.Certificate{}, false
+	}
+	cert, err := tls.X509KeyPair(p, p)
+	if err != nil {
+		return tls.Certificate{}, false
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return tls.Certificate{}, false
+	}
+	if relative {
+		half := leaf.NotBefore.Add(leaf.NotAfter.Sub(leaf.NotBefore) / 2)
+		if now.Before(leaf.NotBefore) || !now.Before(half) {
+			return tls.Certificate{}, false
+		}
+	}
+	return cert, true
+}
+
+// put stores the certificate of key into c.
+func (c *Cache) put(key string, cert tls.Certificate) error {
+	var buf bytes.Buffer
+	if err := WriteCertificateChain(&buf, cert); err != nil {
+		return err
+	}
+	if err := WritePrivateKey(&buf, cert); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path(key), buf.Bytes(), 0600)
+}
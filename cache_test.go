@@ -0,0 +1,122 @@
+package cert4now_test
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func TestCache(t *testing.T) {
+	cache := cert4now.NewCache(filepath.Join(t.TempDir(), "cache"))
+	ca := generateCA(t, cert4now.CommonName("Cache CA"), cert4now.ECDSA(elliptic.P256()))
+
+	generate := func(options ...cert4now.Option) []byte {
+		t.Helper()
+		defaults := []cert4now.Option{cert4now.UseCache(cache), cert4now.Authority(ca), cert4now.Names("localhost")}
+		cert, err := cert4now.Generate(append(defaults, options...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cert.PrivateKey == nil || len(cert.Certificate) == 0 {
+			t.Fatal("incomplete certificate")
+		}
+		return cert.Certificate[0]
+	}
+
+	first := generate()
+	if again := generate(); !bytes.Equal(first, again) {
+		t.Error("want the cached certificate")
+	}
+	if other := generate(cert4now.Names("www.example.com")); bytes.Equal(first, other) {
+		t.Error("want a new certificate of the other names")
+	}
+	if other := generate(cert4now.AddDate(0, 0, 30)); bytes.Equal(first, other) {
+		t.Error("want a new certificate of the other lifetime")
+	}
+	if other := generate(cert4now.ECDSA(elliptic.P256())); bytes.Equal(first, other) {
+		t.Error("want a new certificate of the other key")
+	}
+
+	notAfter := time.Now().AddDate(0, 0, 10)
+	fixed := generate(cert4now.NotAfter(notAfter))
+	if again := generate(cert4now.NotAfter(notAfter)); !bytes.Equal(fixed, again) {
+		t.Error("want the cached certificate of the fixed NotAfter")
+	}
+	if other := generate(cert4now.NotAfter(notAfter.Add(time.Minute))); bytes.Equal(fixed, other) {
+		t.Error("want a new certificate of the other NotAfter")
+	}
+
+	past := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	expired := generate(cert4now.NotBefore(past), cert4now.AddDate(1, 0, 0), cert4now.NoLint())
+	if again := generate(cert4now.NotBefore(past), cert4now.AddDate(1, 0, 0), cert4now.NoLint()); !bytes.Equal(expired, again) {
+		t.Error("want the cached certificate of the fixed validity")
+	}
+
+	entries, err := os.ReadDir(cache.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 7; len(entries) != want {
+		t.Errorf("want %d entries, got %d", want, len(entries))
+	}
+
+	if err := cache.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if again := generate(); bytes.Equal(first, again) {
+		t.Error("want a new certificate after Clear")
+	}
+}
+
+func TestCache_inventory(t *testing.T) {
+	cache := cert4now.NewCache(t.TempDir())
+	store := cert4now.NewMemoryStore()
+	for i := 0; i < 2; i++ {
+		if _, err := cert4now.Generate(cert4now.UseCache(cache), cert4now.Inventory(store), cert4now.Ed25519()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := os.ReadDir(cache.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("want no entries, got %d", len(entries))
+	}
+}
+
+func TestCache_lintStrict(t *testing.T) {
+	cache := cert4now.NewCache(t.TempDir())
+	options := []cert4now.Option{cert4now.UseCache(cache), cert4now.Names("localhost"), cert4now.RSA(1024)}
+	if _, err := cert4now.Generate(append(options, cert4now.NoLint())...); err != nil {
+		t.Fatal(err)
+	}
+	_, err := cert4now.Generate(append(options, cert4now.LintStrict())...)
+	var lerr *cert4now.LintError
+	if !errors.As(err, &lerr) {
+		t.Fatalf("want LintError of the cached certificate, got %v", err)
+	}
+}
+
+func TestCache_serialRegistry(t *testing.T) {
+	cache := cert4now.NewCache(t.TempDir())
+	registry := cert4now.NewMemorySerialRegistry()
+	for i := 0; i < 2; i++ {
+		if _, err := cert4now.Generate(cert4now.UseCache(cache), cert4now.UseSerialRegistry(registry), cert4now.Ed25519()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := os.ReadDir(cache.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("want no entries, got %d", len(entries))
+	}
+}
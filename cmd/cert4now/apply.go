@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func apply(args []string) error {
+	fs := newFlagSet("apply")
+	var filename string
+	fs.StringVar(&filename, "f", "", "file of the PKI spec in YAML or JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if filename == "" {
+		return errors.New("-f is required")
+	}
+
+	spec, err := cert4now.LoadPKISpec(filename)
+	if err != nil {
+		return err
+	}
+	pki, err := spec.Materialize()
+	if err != nil {
+		return err
+	}
+	generated := make(map[string]bool)
+	for _, name := range pki.Generated {
+		generated[name] = true
+	}
+	for _, c := range spec.Certificates {
+		state := "unchanged"
+		if generated[c.Name] {
+			state = "generated"
+		}
+		fmt.Printf("%s %s\n", c.Name, state)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func ca(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "init":
+			return caInit(args[1:])
+		case "revoke":
+			return caRevoke(args[1:])
+		case "list":
+			return caList(args[1:])
+		}
+	}
+	return errors.New("usage: cert4now ca init|revoke|list [flags]")
+}
+
+func caInit(args []string) error {
+	fs := newFlagSet("ca init")
+	var cf certFlags
+	var caf caFlags
+	var parent caFlags
+	caf.register(fs, "dir", "ca")
+	fs.StringVar(&parent.dir, "parent", "", "directory of the certificate authority issuing an intermediate certificate authority")
+	fs.StringVar(&parent.passwordEnv, "parent-password-env", "CERT4NOW_PARENT_PASSWORD", "environment variable holding the password of the private key of the parent")
+	cf.registerSubject(fs)
+	cf.registerKey(fs)
+	fs.IntVar(&cf.years, "years", 10, "years of validity")
+	fs.IntVar(&cf.months, "months", 0, "months of validity")
+	fs.IntVar(&cf.days, "days", 0, "days of validity")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if cf.commonName == "" {
+		cf.commonName = "cert4now CA"
+	}
+	cf.isCA = true
+	cf.keyUsage = listFlag{"digitalSignature", "certSign", "crlSign"}
+	cf.extKeyUsage = listFlag{"none"}
+
+	options, err := cf.options()
+	if err != nil {
+		return err
+	}
+	if parent.dir != "" {
+		p, err := parent.open()
+		if err != nil {
+			return err
+		}
+		cert, err := p.Issue(options...)
+		if err != nil {
+			return err
+		}
+		_, err = cert4now.InitCA(caf.dir, caf.password(), cert)
+		return err
+	}
+	_, err = cert4now.CreateCA(caf.dir, caf.password(), options...)
+	return err
+}
+
+func caRevoke(args []string) error {
+	fs := newFlagSet("ca revoke")
+	var caf caFlags
+	var serial string
+	var reason int
+	caf.register(fs, "dir", "ca")
+	fs.StringVar(&serial, "serial", "", "serial number in hex of the certificate to revoke")
+	fs.IntVar(&reason, "reason", 0, "CRLReason of RFC 5280")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	n, ok := new(big.Int).SetString(serial, 16)
+	if !ok {
+		return fmt.Errorf("invalid serial number %q", serial)
+	}
+
+	ca, err := caf.open()
+	if err != nil {
+		return err
+	}
+	return ca.Revoke(n, reason)
+}
+
+func caList(args []string) error {
+	fs := newFlagSet("ca list")
+	var caf caFlags
+	var q cert4now.Query
+	var expiresWithin time.Duration
+	caf.register(fs, "dir", "ca")
+	fs.StringVar(&q.SerialNumber, "serial", "", "serial number in hex")
+	fs.StringVar(&q.Name, "name", "", "pattern of the names such as *.staging")
+	fs.DurationVar(&expiresWithin, "expires-within", 0, "list only the certificates expiring within the duration")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if expiresWithin > 0 {
+		q.ExpiresBefore = time.Now().Add(expiresWithin)
+	}
+
+	ca, err := caf.open()
+	if err != nil {
+		return err
+	}
+	issued, err := ca.Store().Find(context.Background(), q)
+	if err != nil {
+		return err
+	}
+	for _, v := range issued {
+		fmt.Printf("%s\t%s\t%s\n", v.SerialNumber, v.NotAfter.Format("2006-01-02"), v.Subject)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"os"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func csr(args []string) error {
+	fs := newFlagSet("csr")
+	var cf certFlags
+	var csrFile, keyFile string
+	fs.StringVar(&csrFile, "csr", "csr.pem", "file to write the certificate signing request into")
+	fs.StringVar(&keyFile, "key", "key.pem", "file to write the private key into")
+	cf.registerSubject(fs)
+	cf.registerKey(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	key, err := cf.keyOption()
+	if err != nil {
+		return err
+	}
+	der, signer, err := cert4now.GenerateRequest(append(cf.subjectOptions(), key)...)
+	if err != nil {
+		return err
+	}
+
+	if err := cert4now.WritePrivateKeyFile(keyFile, tls.Certificate{PrivateKey: signer}, 0600); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := cert4now.WriteCertificateRequest(&buf, der); err != nil {
+		return err
+	}
+	return os.WriteFile(csrFile, buf.Bytes(), 0644)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func writeCertificate(out *outputFlags, cert tls.Certificate) error {
+	var buf bytes.Buffer
+	var err error
+	if out.chain {
+		err = cert4now.WriteCertificateChain(&buf, cert)
+	} else {
+		err = cert4now.WriteCertificate(&buf, cert)
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out.cert, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	if out.key != "" && cert.PrivateKey != nil {
+		if err := cert4now.WritePrivateKeyFile(out.key, cert, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// caFlags holds the flags of the certificate authority directory.
+type caFlags struct {
+	dir         string
+	passwordEnv string
+}
+
+func (f *caFlags) register(fs *flag.FlagSet, name, dir string) {
+	fs.StringVar(&f.dir, name, dir, "directory of the certificate authority")
+	fs.StringVar(&f.passwordEnv, "password-env", "CERT4NOW_CA_PASSWORD", "environment variable holding the password of the private key of the certificate authority")
+}
+
+func (f *caFlags) password() []byte {
+	return []byte(os.Getenv(f.passwordEnv))
+}
+
+func (f *caFlags) open() (*cert4now.CA, error) {
+	if f.dir == "" {
+		return nil, errors.New("-ca is required")
+	}
+	return cert4now.OpenCA(f.dir, f.password())
+}
+
+// readCertificates reads every certificate in the PEM file of filename.
+func readCertificates(filename string) ([]*x509.Certificate, error) {
+	p, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, p = pem.Decode(p)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		certs = append(certs, c)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%s: no certificate found", filename)
+	}
+	return certs, nil
+}
+
+func readCertificateRequest(filename string) (*x509.CertificateRequest, error) {
+	p, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(p); block != nil {
+		p = block.Bytes
+	}
+	return x509.ParseCertificateRequest(p)
+}
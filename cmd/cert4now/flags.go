@@ -0,0 +1,216 @@
+package main
+
+import (
+	"crypto/elliptic"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/takumakei/go-cert4now"
+	"github.com/takumakei/go-exit"
+)
+
+// listFlag is a flag accepting comma separated values, also accepting the flag more than once.
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(s string) error {
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*f = append(*f, v)
+		}
+	}
+	return nil
+}
+
+// certFlags holds the flags mapping onto the options of cert4now.
+type certFlags struct {
+	subject     string
+	commonName  string
+	names       listFlag
+	local       bool
+	keyType     string
+	rsaBits     int
+	curve       string
+	years       int
+	months      int
+	days        int
+	keyUsage    listFlag
+	extKeyUsage listFlag
+	isCA        bool
+	profile     string
+
+	fs *flag.FlagSet // the flag set of the validity flags, to find whether they are given
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("cert4now "+name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+// parseFlags parses args, then returns flag.ErrHelp on -h, or the exit status 2 on the other errors
+// since the flag package has already reported them.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err == nil || err == flag.ErrHelp {
+		return err
+	}
+	return exit.Status(2)
+}
+
+func (f *certFlags) registerSubject(fs *flag.FlagSet) {
+	fs.StringVar(&f.subject, "subject", "", "subject such as CN=foo,O=Acme,C=JP or /C=JP/O=Acme/CN=foo")
+	fs.StringVar(&f.commonName, "cn", "", "common name, replacing the one of -subject")
+	fs.Var(&f.names, "names", "comma separated DNS names and IP addresses")
+	fs.BoolVar(&f.local, "local", false, "add the names and the addresses of the local host")
+}
+
+func (f *certFlags) registerKey(fs *flag.FlagSet) {
+	fs.StringVar(&f.keyType, "key-type", "rsa", "key type: rsa, ecdsa or ed25519")
+	fs.IntVar(&f.rsaBits, "rsa-bits", 2048, "size of the RSA key")
+	fs.StringVar(&f.curve, "curve", "P256", "curve of the ECDSA key: P256, P384 or P521")
+}
+
+func (f *certFlags) registerCert(fs *flag.FlagSet, years, months, days int) {
+	f.fs = fs
+	fs.IntVar(&f.years, "years", years, "years of validity, zero of all the validity flags means the default")
+	fs.IntVar(&f.months, "months", months, "months of validity")
+	fs.IntVar(&f.days, "days", days, "days of validity")
+	fs.Var(&f.keyUsage, "key-usage", "comma separated key usages: "+keyUsageHelp)
+	fs.Var(&f.extKeyUsage, "ext-key-usage", "comma separated extended key usages: "+extKeyUsageHelp+" or none")
+	fs.BoolVar(&f.isCA, "is-ca", false, "issue a certificate of a certificate authority")
+	fs.StringVar(&f.profile, "profile", "", "profile of the certificate: "+strings.Join(cert4now.ProfileNames(), ", ")+", whose validity is used unless a validity flag is given")
+}
+
+func (f *certFlags) register(fs *flag.FlagSet, years, months, days int) {
+	f.registerSubject(fs)
+	f.registerKey(fs)
+	f.registerCert(fs, years, months, days)
+}
+
+func (f *certFlags) subjectOptions() []cert4now.Option {
+	var options []cert4now.Option
+	if f.subject != "" {
+		options = append(options, cert4now.SubjectString(f.subject))
+	}
+	if f.commonName != "" {
+		options = append(options, cert4now.CommonName(f.commonName))
+	}
+	if len(f.names) > 0 {
+		options = append(options, cert4now.Names(f.names...))
+	}
+	if f.local {
+		options = append(options, cert4now.LocalNames())
+	}
+	return options
+}
+
+func (f *certFlags) keyOption() (cert4now.Option, error) {
+	switch strings.ToLower(f.keyType) {
+	case "rsa":
+		return cert4now.RSA(f.rsaBits), nil
+	case "ecdsa", "ec":
+		var c elliptic.Curve
+		switch strings.ToUpper(strings.TrimPrefix(strings.ToUpper(f.curve), "P-")) {
+		case "P256", "256":
+			c = elliptic.P256()
+		case "P384", "384":
+			c = elliptic.P384()
+		case "P521", "521":
+			c = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unknown curve %q", f.curve)
+		}
+		return cert4now.ECDSA(c), nil
+	case "ed25519":
+		return cert4now.Ed25519(), nil
+	}
+	return nil, fmt.Errorf("unknown key type %q", f.keyType)
+}
+
+func (f *certFlags) certOptions() ([]cert4now.Option, error) {
+	var options []cert4now.Option
+	if f.profile != "" {
+		options = append(options, cert4now.UseProfile(f.profile))
+	}
+	if f.isCA || f.profile == "" {
+		options = append(options, cert4now.IsCA(f.isCA))
+	}
+	// The default validity of the flags would override the one of the profile.
+	if (f.profile == "" || f.validityGiven()) && (f.years != 0 || f.months != 0 || f.days != 0) {
+		options = append(options, cert4now.AddDate(f.years, f.months, f.days))
+	}
+	if len(f.keyUsage) > 0 {
+		usage, err := cert4now.ParseKeyUsage(f.keyUsage...)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, cert4now.KeyUsage(usage))
+	}
+	if len(f.extKeyUsage) > 0 {
+		var names []string
+		for _, v := range f.extKeyUsage {
+			if !strings.EqualFold(v, "none") {
+				names = append(names, v)
+			}
+		}
+		usage, err := cert4now.ParseExtKeyUsage(names...)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, cert4now.ExtKeyUsage(usage...))
+	}
+	return options, nil
+}
+
+// validityGiven reports whether any of the validity flags is given in the command line.
+func (f *certFlags) validityGiven() bool {
+	given := false
+	if f.fs != nil {
+		f.fs.Visit(func(v *flag.Flag) {
+			switch v.Name {
+			case "years", "months", "days":
+				given = true
+			}
+		})
+	}
+	return given
+}
+
+func (f *certFlags) options() ([]cert4now.Option, error) {
+	key, err := f.keyOption()
+	if err != nil {
+		return nil, err
+	}
+	options, err := f.certOptions()
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, key)
+	return append(options, f.subjectOptions()...), nil
+}
+
+const (
+	keyUsageHelp    = "digitalSignature, contentCommitment, keyEncipherment, dataEncipherment, keyAgreement, certSign, crlSign, encipherOnly, decipherOnly"
+	extKeyUsageHelp = "any, serverAuth, clientAuth, codeSigning, emailProtection, timeStamping, ocspSigning"
+)
+
+// outputFlags holds the flags of the files to write.
+type outputFlags struct {
+	cert  string
+	key   string
+	chain bool
+}
+
+func (f *outputFlags) register(fs *flag.FlagSet, cert, key string) {
+	fs.StringVar(&f.cert, "cert", cert, "file to write the certificate into")
+	if key != "" {
+		fs.StringVar(&f.key, "key", key, "file to write the private key into")
+	}
+	fs.BoolVar(&f.chain, "chain", true, "write the chain of the certificate following the certificate")
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"github.com/takumakei/go-cert4now"
+)
+
+func gen(args []string) error {
+	fs := newFlagSet("gen")
+	var cf certFlags
+	var out outputFlags
+	cf.register(fs, 0, 0, 90)
+	out.register(fs, "cert.pem", "key.pem")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	options, err := cf.options()
+	if err != nil {
+		return err
+	}
+	cert, err := cert4now.Generate(options...)
+	if err != nil {
+		return err
+	}
+	return writeCertificate(&out, cert)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func inspect(args []string) error {
+	fs := newFlagSet("inspect")
+	var asJSON bool
+	fs.BoolVar(&asJSON, "json", false, "print in JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("usage: cert4now inspect [-json] file...")
+	}
+
+	for _, filename := range fs.Args() {
+		certs, err := readCertificates(filename)
+		if err != nil {
+			return err
+		}
+		var cert tls.Certificate
+		for _, c := range certs {
+			cert.Certificate = append(cert.Certificate, c.Raw)
+		}
+		d, err := cert4now.Describe(cert)
+		if err != nil {
+			return err
+		}
+
+		if asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(d); err != nil {
+				return err
+			}
+			continue
+		}
+		if fs.NArg() > 1 {
+			fmt.Printf("%s:\n", filename)
+		}
+		if err := d.WriteText(os.Stdout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package main
+
+func issue(args []string) error {
+	fs := newFlagSet("issue")
+	var cf certFlags
+	var out outputFlags
+	var caf caFlags
+	caf.register(fs, "ca", "")
+	cf.register(fs, 0, 0, 0)
+	out.register(fs, "cert.pem", "key.pem")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	ca, err := caf.open()
+	if err != nil {
+		return err
+	}
+	options, err := cf.options()
+	if err != nil {
+		return err
+	}
+	cert, err := ca.Issue(options...)
+	if err != nil {
+		return err
+	}
+	return writeCertificate(&out, cert)
+}
@@ -0,0 +1,82 @@
+// Command cert4now generates certificates from the command line.
+//
+// Usage:
+//
+//	cert4now gen     [flags]                    generate a self signed certificate
+//	cert4now ca init [flags]                    create a certificate authority
+//	cert4now issue   -ca dir [flags]            issue a certificate signed by the authority
+//	cert4now csr     [flags]                    generate a private key and a certificate signing request
+//	cert4now sign    -ca dir -csr file [flags]  issue a certificate for the certificate signing request
+//	cert4now inspect file...                    print certificates
+//	cert4now verify  -roots file [flags] file   verify a certificate chain
+//	cert4now apply   -f file                    make the files converge to the PKI spec
+//
+// Run "cert4now <command> -h" for the flags of each command.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/takumakei/go-exit"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"gen", "generate a self signed certificate", gen},
+		{"ca", "manage a certificate authority", ca},
+		{"issue", "issue a certificate signed by the authority", issue},
+		{"csr", "generate a private key and a certificate signing request", csr},
+		{"sign", "issue a certificate for the certificate signing request", sign},
+		{"inspect", "print certificates", inspect},
+		{"verify", "verify a certificate chain", verify},
+		{"apply", "make the files converge to the PKI spec", apply},
+		{"help", "print this help", help},
+	}
+}
+
+func main() {
+	log.SetFlags(0)
+	exit.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) error {
+	if len(args) > 0 {
+		for _, cmd := range commands {
+			if cmd.name == args[0] {
+				err := cmd.run(args[1:])
+				if err == flag.ErrHelp {
+					return nil
+				}
+				return err
+			}
+		}
+		fmt.Fprintf(os.Stderr, "cert4now: unknown command %q\n", args[0])
+	}
+	usage()
+	return exit.Status(2)
+}
+
+func help([]string) error {
+	usage()
+	return nil
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: cert4now <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.usage)
+	}
+}
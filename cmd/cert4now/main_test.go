@@ -0,0 +1,243 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/takumakei/go-exit"
+)
+
+const testPasswordEnv = "CERT4NOW_TEST_PASSWORD"
+
+// runCommand runs the command line of args, then returns what it printed to the standard output.
+func runCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	stdout, stderr := os.Stdout, os.Stderr
+	defer func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		log.SetOutput(stderr)
+	}()
+
+	dir := t.TempDir()
+	out, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	errOut, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer errOut.Close()
+	os.Stdout, os.Stderr = out, errOut
+	log.SetOutput(errOut)
+
+	err = run(args)
+	if _, serr := out.Seek(0, io.SeekStart); serr != nil {
+		t.Fatal(serr)
+	}
+	p, rerr := io.ReadAll(out)
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	return string(p), err
+}
+
+func readCertificate(t *testing.T, filename string) *x509.Certificate {
+	t.Helper()
+	certs, err := readCertificates(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certs[0]
+}
+
+func lifetimeDays(c *x509.Certificate) int {
+	return int(c.NotAfter.Sub(c.NotBefore).Round(24*time.Hour) / (24 * time.Hour))
+}
+
+func TestRun_exitStatus(t *testing.T) {
+	cases := []struct {
+		Args []string
+		Code int
+	}{
+		{nil, 2},
+		{[]string{"unknown"}, 2},
+		{[]string{"help"}, 0},
+		{[]string{"gen", "-h"}, 0},
+		{[]string{"gen", "-unknown"}, 2},
+		{[]string{"gen", "-days", "x"}, 2},
+		{[]string{"gen", "-key-type", "dsa"}, 1},
+		{[]string{"ca"}, 1},
+		{[]string{"issue"}, 1},
+		{[]string{"sign", "-ca", "ca"}, 1},
+		{[]string{"inspect"}, 1},
+		{[]string{"verify"}, 1},
+		{[]string{"apply"}, 1},
+	}
+	for i, c := range cases {
+		_, err := runCommand(t, c.Args...)
+		if code := exit.StatusCode(err); code != c.Code {
+			t.Errorf("[%d] %q: want exit status %d, got %d (%v)", i, c.Args, c.Code, code, err)
+		}
+	}
+}
+
+func TestGen(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+
+	if _, err := runCommand(t, "gen", "-cert", certFile, "-key", keyFile, "-names", "localhost,127.0.0.1", "-key-type", "ecdsa", "-curve", "P-384"); err != nil {
+		t.Fatal(err)
+	}
+	c := readCertificate(t, certFile)
+	if len(c.DNSNames) != 1 || c.DNSNames[0] != "localhost" || len(c.IPAddresses) != 1 {
+		t.Errorf("unexpected names %v %v", c.DNSNames, c.IPAddresses)
+	}
+	if c.PublicKeyAlgorithm != x509.ECDSA {
+		t.Errorf("want ECDSA, got %v", c.PublicKeyAlgorithm)
+	}
+	if days := lifetimeDays(c); days != 90 {
+		t.Errorf("want 90 days by default, got %d", days)
+	}
+	if _, err := os.Stat(keyFile); err != nil {
+		t.Error(err)
+	}
+
+	// The validity of the profile is kept unless a validity flag is given.
+	if _, err := runCommand(t, "gen", "-cert", certFile, "-key", keyFile, "-profile", "root-ca", "-cn", "Root"); err != nil {
+		t.Fatal(err)
+	}
+	c = readCertificate(t, certFile)
+	if !c.IsCA || lifetimeDays(c) != 20*365 {
+		t.Errorf("want CA of 20 years, got IsCA %v of %d days", c.IsCA, lifetimeDays(c))
+	}
+	if _, err := runCommand(t, "gen", "-cert", certFile, "-key", keyFile, "-profile", "root-ca", "-cn", "Root", "-days", "10"); err != nil {
+		t.Fatal(err)
+	}
+	if days := lifetimeDays(readCertificate(t, certFile)); days != 10 {
+		t.Errorf("want 10 days of -days, got %d", days)
+	}
+}
+
+func TestCA(t *testing.T) {
+	if err := os.Setenv(testPasswordEnv, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Unsetenv(testPasswordEnv) })
+
+	dir := t.TempDir()
+	caDir := filepath.Join(dir, "ca")
+	path := func(name string) string { return filepath.Join(dir, name) }
+	mustRun := func(args ...string) string {
+		t.Helper()
+		out, err := runCommand(t, args...)
+		if err != nil {
+			t.Fatalf("%q: %v", args, err)
+		}
+		return out
+	}
+
+	mustRun("ca", "init", "-dir", caDir, "-password-env", testPasswordEnv, "-cn", "Test CA", "-key-type", "ecdsa")
+	root := filepath.Join(caDir, "ca.crt")
+	if c := readCertificate(t, root); !c.IsCA || c.Subject.CommonName != "Test CA" {
+		t.Fatalf("unexpected CA %v", c.Subject)
+	}
+
+	mustRun("issue", "-ca", caDir, "-password-env", testPasswordEnv, "-names", "www.example.com", "-days", "30", "-cert", path("www.pem"), "-key", path("www.key"))
+	www := readCertificate(t, path("www.pem"))
+	if www.SerialNumber.Int64() != 1 || lifetimeDays(www) != 30 {
+		t.Errorf("want serial 1 of 30 days, got %v of %d days", www.SerialNumber, lifetimeDays(www))
+	}
+	if _, err := runCommand(t, "issue", "-ca", caDir, "-password-env", "CERT4NOW_TEST_NO_PASSWORD", "-names", "www.example.com"); err == nil {
+		t.Error("want the error of the wrong password")
+	}
+
+	mustRun("csr", "-csr", path("req.pem"), "-key", path("req.key"), "-cn", "api", "-names", "api.example.com", "-key-type", "ed25519")
+	mustRun("sign", "-ca", caDir, "-password-env", testPasswordEnv, "-csr", path("req.pem"), "-cert", path("api.pem"))
+	if api := readCertificate(t, path("api.pem")); api.SerialNumber.Int64() != 2 || api.PublicKeyAlgorithm != x509.Ed25519 {
+		t.Errorf("want serial 2 of Ed25519, got %v of %v", api.SerialNumber, api.PublicKeyAlgorithm)
+	}
+
+	if out := mustRun("ca", "list", "-dir", caDir, "-password-env", testPasswordEnv, "-name", "api.*"); strings.Count(out, "\n") != 1 || !strings.HasPrefix(out, "2\t") {
+		t.Errorf("unexpected list %q", out)
+	}
+
+	out := mustRun("verify", "-roots", root, "-name", "www.example.com", path("www.pem"))
+	if !strings.HasSuffix(out, "OK\n") {
+		t.Errorf("unexpected verify %q", out)
+	}
+	out, err := runCommand(t, "verify", "-roots", root, "-name", "mail.example.com", path("www.pem"))
+	if code := exit.StatusCode(err); code != 1 || !strings.HasSuffix(out, "FAILED\n") {
+		t.Errorf("want FAILED of exit status 1, got %q of %d", out, code)
+	}
+
+	if out := mustRun("inspect", path("www.pem")); !strings.Contains(out, "Subject Alternative Name: DNS:www.example.com") {
+		t.Errorf("unexpected inspect %q", out)
+	}
+	var d struct {
+		Certificates []struct {
+			SerialNumber string `json:"serialNumber"`
+		} `json:"certificates"`
+	}
+	if err := json.Unmarshal([]byte(mustRun("inspect", "-json", path("www.pem"))), &d); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Certificates) != 2 {
+		t.Errorf("want the certificate and its chain, got %+v", d)
+	}
+
+	mustRun("ca", "revoke", "-dir", caDir, "-password-env", testPasswordEnv, "-serial", "1", "-reason", "1")
+	if _, err := runCommand(t, "ca", "revoke", "-dir", caDir, "-password-env", testPasswordEnv, "-serial", "1"); err == nil {
+		t.Error("want the error of revoking twice")
+	}
+	if _, err := runCommand(t, "ca", "revoke", "-dir", caDir, "-password-env", testPasswordEnv, "-serial", "xyz"); err == nil {
+		t.Error("want the error of the invalid serial")
+	}
+	if _, err := os.Stat(filepath.Join(caDir, "crl.pem")); err != nil {
+		t.Error(err)
+	}
+}
+
+const testPKISpec = `
+certificates:
+  - name: root
+    ca: true
+    subject: {commonName: "Test Root CA"}
+    key: ecdsa
+    output: {cert: root.crt, key: root.key}
+  - name: web
+    issuer: root
+    subject: {commonName: localhost}
+    names: [localhost]
+    key: ecdsa
+    output: {cert: web.crt, key: web.key}
+`
+
+func TestApply(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "pki.yaml")
+	if err := os.WriteFile(filename, []byte(testPKISpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"generated", "unchanged"} {
+		out, err := runCommand(t, "apply", "-f", filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := "root " + want + "\nweb " + want + "\n"; out != expected {
+			t.Errorf("want %q, got %q", expected, out)
+		}
+	}
+	if c := readCertificate(t, filepath.Join(dir, "web.crt")); c.Subject.CommonName != "localhost" {
+		t.Errorf("unexpected subject %v", c.Subject)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func sign(args []string) error {
+	fs := newFlagSet("sign")
+	var cf certFlags
+	var out outputFlags
+	var caf caFlags
+	var csrFile string
+	caf.register(fs, "ca", "")
+	fs.StringVar(&csrFile, "csr", "", "file of the certificate signing request")
+	cf.registerSubject(fs)
+	cf.registerCert(fs, 0, 0, 0)
+	out.register(fs, "cert.pem", "")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if csrFile == "" {
+		return errors.New("-csr is required")
+	}
+
+	ca, err := caf.open()
+	if err != nil {
+		return err
+	}
+	req, err := readCertificateRequest(csrFile)
+	if err != nil {
+		return err
+	}
+	options, err := cf.certOptions()
+	if err != nil {
+		return err
+	}
+	options = append(options, cf.subjectOptions()...)
+	options = append(options, cert4now.CertificateRequest(req))
+
+	cert, err := ca.Issue(options...)
+	if err != nil {
+		return err
+	}
+	return writeCertificate(&out, cert)
+}
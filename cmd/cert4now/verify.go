@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/takumakei/go-cert4now"
+	"github.com/takumakei/go-exit"
+)
+
+func verify(args []string) error {
+	fs := newFlagSet("verify")
+	var roots, eku listFlag
+	var name string
+	fs.Var(&roots, "roots", "comma separated files of the trusted root certificates")
+	fs.StringVar(&name, "name", "", "DNS name or IP address to verify the certificate for")
+	fs.Var(&eku, "eku", "comma separated extended key usages the certificate must be valid for any of (default any)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || len(roots) == 0 {
+		return errors.New("usage: cert4now verify -roots file [flags] file")
+	}
+
+	var options []cert4now.VerifyOption
+	if name != "" {
+		options = append(options, cert4now.VerifyName(name))
+	}
+	usage := []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	if len(eku) > 0 {
+		var err error
+		if usage, err = cert4now.ParseExtKeyUsage(eku...); err != nil {
+			return err
+		}
+	}
+	options = append(options, cert4now.VerifyExtKeyUsage(usage...))
+
+	var rootCerts []tls.Certificate
+	for _, filename := range roots {
+		certs, err := readCertificates(filename)
+		if err != nil {
+			return err
+		}
+		for _, c := range certs {
+			rootCerts = append(rootCerts, tls.Certificate{Certificate: [][]byte{c.Raw}})
+		}
+	}
+	certs, err := readCertificates(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	var cert tls.Certificate
+	for _, c := range certs {
+		cert.Certificate = append(cert.Certificate, c.Raw)
+	}
+
+	chain, err := cert4now.Verify(cert, rootCerts, options...)
+	var verr *cert4now.VerifyError
+	if errors.As(err, &verr) {
+		for _, p := range verr.Problems {
+			fmt.Fprintln(os.Stderr, p)
+		}
+		fmt.Println("FAILED")
+		return exit.Status(1)
+	}
+	if err != nil {
+		return err
+	}
+	for _, c := range chain {
+		fmt.Println(c.Subject)
+	}
+	fmt.Println("OK")
+	return nil
+}
@@ -0,0 +1,65 @@
+package cert4now
+
+import (
+	"context"
+	"crypto"
+	"io"
+)
+
+// ContextSigner is crypto.Signer able to cancel signing, such as the signer of a remote key.
+// GenerateContext signs the certificate with SignContext if the authority key is ContextSigner.
+type ContextSigner interface {
+	crypto.Signer
+	SignContext(ctx context.Context, rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error)
+}
+
+// contextSigner binds ctx to the calls of Sign.
+type contextSigner struct {
+	ctx    context.Context
+	signer crypto.Signer
+}
+
+func withContext(ctx context.Context, signer crypto.Signer) crypto.Signer {
+	return &contextSigner{ctx: ctx, signer: signer}
+}
+
+func (s *contextSigner) Public() crypto.PublicKey {
+	return s.signer.Public()
+}
+
+func (s *contextSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if signer, ok := s.signer.(ContextSigner); ok {
+		return signer.SignContext(s.ctx, rand, digest, opts)
+	}
+	return s.signer.Sign(rand, digest, opts)
+}
+
+// newSignerContext returns the private key of newSigner, or the error of ctx if ctx is done first.
+// The key being generated is discarded when ctx is done.
+func (p *param) newSignerContext(ctx context.Context) (crypto.Signer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if p.fixedKey {
+		return p.genSigner()
+	}
+
+	type result struct {
+		signer crypto.Signer
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		signer, err := p.newSigner()
+		done <- result{signer, err}
+	}()
+	select {
+	case r := <-done:
+		return r.signer, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,60 @@
+package cert4now_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/elliptic"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+// blockingSigner is ContextSigner blocking until the context is done.
+type blockingSigner struct {
+	crypto.Signer
+	called chan struct{}
+}
+
+func (s *blockingSigner) SignContext(ctx context.Context, rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	close(s.called)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestGenerateContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := cert4now.GenerateContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := cert4now.GenerateContext(ctx, cert4now.RSA(4096)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v to return", elapsed)
+	}
+}
+
+func TestGenerateContext_signer(t *testing.T) {
+	ca := generateCA(t, cert4now.ECDSA(elliptic.P256()))
+	signer := &blockingSigner{Signer: ca.PrivateKey.(crypto.Signer), called: make(chan struct{})}
+	ca.PrivateKey = signer
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-signer.called
+		cancel()
+	}()
+	_, err := cert4now.GenerateContext(ctx, cert4now.Authority(ca), cert4now.ECDSA(elliptic.P256()), cert4now.Names("localhost"))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+
+}
@@ -0,0 +1,99 @@
+package cert4now
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+)
+
+// ErrNoCertificate represents the certificate to re-sign has no certificate.
+var ErrNoCertificate = errors.New("certificate to re-sign has no certificate")
+
+// oidsGenerated are the extensions generated by the authority signing the certificate.
+var oidsGenerated = []asn1.ObjectIdentifier{
+	{2, 5, 29, 14}, // subject key identifier
+	{2, 5, 29, 35}, // authority key identifier
+}
+
+// oidsIssuerBound are the extensions about the authority that signed the certificate, wrong for another authority.
+var oidsIssuerBound = []asn1.ObjectIdentifier{
+	{1, 3, 6, 1, 5, 5, 7, 1, 1},        // authority information access
+	{2, 5, 29, 31},                     // CRL distribution points
+	{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}, // signed certificate timestamp list
+}
+
+// oidsNotReissued are the extensions not copied by reissue.
+var oidsNotReissued = append(append([]asn1.ObjectIdentifier{}, oidsGenerated...), oidsIssuerBound...)
+
+// CrossSign returns the certificate of the public key and the subject of cert issued by authority,
+// such as a root cross-signed by another root, or a leaf migrated to a new intermediate.
+// The subject key identifier, the subject alternative names, the validity and the other extensions of cert are kept,
+// so that the certificate makes another chain for the same key.
+// The authority information access, the CRL distribution points and the signed certificate timestamps are dropped,
+// since they belong to the authority of cert.
+// The private key of cert is kept if any.
+//
+// The options such as NotBefore, NotAfter, AddDate, SerialNumber, UseSerialPolicy, SignatureAlgorithm and Inventory take effect,
+// while the options of the subject, the names and the usages are overridden by the extensions of cert.
+func CrossSign(cert tls.Certificate, authority tls.Certificate, options ...Option) (tls.Certificate, error) {
+	leaf, err := leafOf(cert)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	signer, _ := cert.PrivateKey.(crypto.Signer)
+	options = append([]Option{reissue(leaf, signer)}, options...)
+	return Generate(append(options, Authority(authority))...)
+}
+
+// leafOf returns the parsed leaf of cert.
+func leafOf(cert tls.Certificate) (*x509.Certificate, error) {
+	if cert.Leaf != nil {
+		return cert.Leaf, nil
+	}
+	if len(cert.Certificate) == 0 {
+		return nil, ErrNoCertificate
+	}
+	return x509.ParseCertificate(cert.Certificate[0])
+}
+
+// reissue returns an option of issuing the certificate of the same public key, subject, validity and extensions as cert.
+// The private key is signer if not nil, otherwise the certificate has no private key.
+func reissue(cert *x509.Certificate, signer crypto.Signer) Option {
+	return func(p *param) {
+		if signer != nil {
+			Signer(signer)(p)
+		} else {
+			p.publicKey = cert.PublicKey
+			p.keyType = keyTypeOf(cert.PublicKey)
+		}
+		renewal(cert)(p)
+		p.keyUsageSet = true
+		p.notBefore = cert.NotBefore
+		p.notAfter = cert.NotAfter
+		p.subjectKeyID = cert.SubjectKeyId
+		p.extraExtensions = extensionsExcept(cert.Extensions, oidsNotReissued)
+	}
+}
+
+// extensionsExcept returns the extensions of exts other than the ones of oids.
+func extensionsExcept(exts []pkix.Extension, oids []asn1.ObjectIdentifier) []pkix.Extension {
+	var extensions []pkix.Extension
+	for _, ext := range exts {
+		if !containsOID(oids, ext.Id) {
+			extensions = append(extensions, ext)
+		}
+	}
+	return extensions
+}
+
+func containsOID(oids []asn1.ObjectIdentifier, oid asn1.ObjectIdentifier) bool {
+	for _, v := range oids {
+		if v.Equal(oid) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package cert4now_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takumakei/go-cert4now"
+)
+
+func TestCrossSign_leaf(t *testing.T) {
+	oldCA := generateCA(t, cert4now.CommonName("Old CA"))
+	newCA := generateCA(t, cert4now.CommonName("New CA"))
+
+	// The leaf has the extensions that cert4now does not generate.
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom := pkix.Extension{Id: asn1.ObjectIdentifier{1, 2, 3, 4}, Value: []byte{0x05, 0x00}}
+	sct := pkix.Extension{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}, Value: []byte{0x04, 0x00}}
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(1),
+		Subject:         pkix.Name{CommonName: "www.example.com"},
+		NotBefore:       time.Now(),
+		NotAfter:        time.Now().AddDate(0, 0, 30),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		SubjectKeyId:    []byte{1, 2, 3, 4},
+		DNSNames:        []string{"www.example.com"},
+		URIs:            []*url.URL{{Scheme: "spiffe", Host: "example.com", Path: "/www"}},
+		ExtraExtensions: []pkix.Extension{custom, sct},
+
+		// The extensions about the old authority.
+		OCSPServer:            []string{"http://ocsp.example.com"},
+		IssuingCertificateURL: []string{"http://example.com/old.crt"},
+		CRLDistributionPoints: []string{"http://example.com/old.crl"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parseLeaf(t, oldCA), key.Public(), oldCA.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+
+	cert, err := cert4now.CrossSign(leaf, newCA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := parseLeaf(t, cert)
+	if cert.PrivateKey != key {
+		t.Error("private key is not kept")
+	}
+	if !got.PublicKey.(*ecdsa.PublicKey).Equal(key.Public()) {
+		t.Error("public key differs")
+	}
+	original, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.RawSubject, original.RawSubject) {
+		t.Error("subject differs")
+	}
+	if !bytes.Equal(got.RawIssuer, parseLeaf(t, newCA).RawSubject) {
+		t.Error("issuer is not the new authority")
+	}
+	if diff := cmp.Diff(template.SubjectKeyId, got.SubjectKeyId); diff != "" {
+		t.Errorf("SubjectKeyId (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(parseLeaf(t, newCA).SubjectKeyId, got.AuthorityKeyId); diff != "" {
+		t.Errorf("AuthorityKeyId (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(template.DNSNames, got.DNSNames); diff != "" {
+		t.Errorf("DNSNames (-want +got):\n%s", diff)
+	}
+	if len(got.URIs) != 1 || got.URIs[0].String() != "spiffe://example.com/www" {
+		t.Errorf("unexpected URIs %v", got.URIs)
+	}
+	if !got.NotBefore.Equal(original.NotBefore) || !got.NotAfter.Equal(original.NotAfter) {
+		t.Errorf("validity differs: %v - %v", got.NotBefore, got.NotAfter)
+	}
+	found := false
+	for _, ext := range got.Extensions {
+		if ext.Id.Equal(custom.Id) {
+			found = bytes.Equal(ext.Value, custom.Value)
+		}
+	}
+	if !found {
+		t.Error("custom extension is not kept")
+	}
+	for _, ext := range got.Extensions {
+		if ext.Id.Equal(sct.Id) {
+			t.Error("signed certificate timestamps are kept")
+		}
+	}
+	if len(got.OCSPServer) != 0 || len(got.IssuingCertificateURL) != 0 || len(got.CRLDistributionPoints) != 0 {
+		t.Errorf("extensions of the old authority are kept: %v %v %v", got.OCSPServer, got.IssuingCertificateURL, got.CRLDistributionPoints)
+	}
+
+	// Both of the chains are valid.
+	if _, err := cert4now.Verify(cert, []tls.Certificate{newCA}, cert4now.VerifyName("www.example.com")); err != nil {
+		t.Error(err)
+	}
+	if _, err := cert4now.Verify(leaf, []tls.Certificate{oldCA}, cert4now.VerifyName("www.example.com")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCrossSign_root(t *testing.T) {
+	oldRoot := generateCA(t, cert4now.CommonName("Old Root"))
+	newRoot := generateCA(t, cert4now.CommonName("New Root"))
+	leaf, err := cert4now.Generate(cert4now.Authority(oldRoot), cert4now.Names("www.example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cross, err := cert4now.CrossSign(oldRoot, newRoot, cert4now.AddDate(0, 6, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parseLeaf(t, cross).IsCA || !bytes.Equal(parseLeaf(t, cross).SubjectKeyId, parseLeaf(t, oldRoot).SubjectKeyId) {
+		t.Error("cross-signed root differs from the old root")
+	}
+	if got := parseLeaf(t, cross).NotAfter.Sub(parseLeaf(t, cross).NotBefore); got > 190*24*time.Hour {
+		t.Errorf("validity is not overridden: %v", got)
+	}
+
+	// The clients trusting only the new root accept the leaf through the cross-signed root.
+	roots := x509.NewCertPool()
+	roots.AddCert(parseLeaf(t, newRoot))
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(parseLeaf(t, cross))
+	if _, err := parseLeaf(t, leaf).Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates, DNSName: "www.example.com"}); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := cert4now.CrossSign(tls.Certificate{}, newRoot); !errors.Is(err, cert4now.ErrNoCertificate) {
+		t.Errorf("want ErrNoCertificate, got %v", err)
+	}
+}
@@ -0,0 +1,244 @@
+package cert4now
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Description is the human readable description of a certificate and its chain.
+// It is marshaled into JSON in a stable form.
+type Description struct {
+	Certificates []CertificateDescription `json:"certificates"`
+}
+
+// CertificateDescription is the description of a certificate in the chain.
+type CertificateDescription struct {
+	Version            int                  `json:"version"`
+	SerialNumber       string               `json:"serialNumber"`
+	SignatureAlgorithm string               `json:"signatureAlgorithm"`
+	Issuer             string               `json:"issuer"`
+	Subject            string               `json:"subject"`
+	NotBefore          time.Time            `json:"notBefore"`
+	NotAfter           time.Time            `json:"notAfter"`
+	PublicKey          PublicKeyDescription `json:"publicKey"`
+	DNSNames           []string             `json:"dnsNames,omitempty"`
+	IPAddresses        []string             `json:"ipAddresses,omitempty"`
+	EmailAddresses     []string             `json:"emailAddresses,omitempty"`
+	URIs               []string             `json:"uris,omitempty"`
+	KeyUsage           []string             `json:"keyUsage,omitempty"`
+	ExtKeyUsage        []string             `json:"extKeyUsage,omitempty"`
+	BasicConstraints   *BasicConstraints    `json:"basicConstraints,omitempty"`
+	SubjectKeyID       string               `json:"subjectKeyId,omitempty"`
+	AuthorityKeyID     string               `json:"authorityKeyId,omitempty"`
+	SHA1Fingerprint    string               `json:"sha1Fingerprint"`
+	SHA256Fingerprint  string               `json:"sha256Fingerprint"`
+
+	// Chain tells how the certificate relates to the next one in the chain:
+	// "self-signed", "signed by next", "not signed by next", or "issuer not in chain".
+	Chain string `json:"chain"`
+}
+
+// PublicKeyDescription is the description of a public key.
+type PublicKeyDescription struct {
+	Algorithm string `json:"algorithm"`
+	Size      int    `json:"size"`
+	Curve     string `json:"curve,omitempty"`
+}
+
+// BasicConstraints is the description of the basic constraints extension.
+type BasicConstraints struct {
+	CA         bool `json:"ca"`
+	MaxPathLen *int `json:"maxPathLen,omitempty"`
+}
+
+// Describe describes the certificate and its chain of cert in the order of cert.Certificate.
+func Describe(cert tls.Certificate) (*Description, error) {
+	if len(cert.Certificate) == 0 {
+		return nil, errors.New("no certificate to describe")
+	}
+	certs := make([]*x509.Certificate, len(cert.Certificate))
+	for i, der := range cert.Certificate {
+		c, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		certs[i] = c
+	}
+
+	d := &Description{}
+	for i, c := range certs {
+		v := describeCertificate(c)
+		switch {
+		case bytes.Equal(c.RawIssuer, c.RawSubject) && c.CheckSignature(c.SignatureAlgorithm, c.RawTBSCertificate, c.Signature) == nil:
+			v.Chain = "self-signed"
+		case i+1 == len(certs):
+			v.Chain = "issuer not in chain"
+		case c.CheckSignatureFrom(certs[i+1]) == nil:
+			v.Chain = "signed by next"
+		default:
+			v.Chain = "not signed by next"
+		}
+		d.Certificates = append(d.Certificates, v)
+	}
+	return d, nil
+}
+
+func describeCertificate(c *x509.Certificate) CertificateDescription {
+	sum1 := sha1.Sum(c.Raw)
+	sum256 := sha256.Sum256(c.Raw)
+	v := CertificateDescription{
+		Version:            c.Version,
+		SerialNumber:       colonHex(c.SerialNumber.Bytes()),
+		SignatureAlgorithm: c.SignatureAlgorithm.String(),
+		Issuer:             c.Issuer.String(),
+		Subject:            c.Subject.String(),
+		NotBefore:          c.NotBefore.UTC(),
+		NotAfter:           c.NotAfter.UTC(),
+		PublicKey:          describePublicKey(c.PublicKey),
+		DNSNames:           c.DNSNames,
+		EmailAddresses:     c.EmailAddresses,
+		KeyUsage:           KeyUsageNames(c.KeyUsage),
+		SubjectKeyID:       colonHex(c.SubjectKeyId),
+		AuthorityKeyID:     colonHex(c.AuthorityKeyId),
+		SHA1Fingerprint:    colonHex(sum1[:]),
+		SHA256Fingerprint:  colonHex(sum256[:]),
+	}
+	for _, ip := range c.IPAddresses {
+		v.IPAddresses = append(v.IPAddresses, ip.String())
+	}
+	for _, uri := range c.URIs {
+		v.URIs = append(v.URIs, uri.String())
+	}
+	for _, usage := range c.ExtKeyUsage {
+		v.ExtKeyUsage = append(v.ExtKeyUsage, ExtKeyUsageName(usage))
+	}
+	for _, oid := range c.UnknownExtKeyUsage {
+		v.ExtKeyUsage = append(v.ExtKeyUsage, oid.String())
+	}
+	if c.BasicConstraintsValid {
+		v.BasicConstraints = &BasicConstraints{CA: c.IsCA}
+		if c.IsCA && (c.MaxPathLen > 0 || c.MaxPathLenZero) {
+			n := c.MaxPathLen
+			v.BasicConstraints.MaxPathLen = &n
+		}
+	}
+	return v
+}
+
+func describePublicKey(pub interface{}) PublicKeyDescription {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return PublicKeyDescription{Algorithm: "RSA", Size: k.N.BitLen()}
+	case *ecdsa.PublicKey:
+		return PublicKeyDescription{Algorithm: "ECDSA", Size: k.Curve.Params().BitSize, Curve: k.Curve.Params().Name}
+	case ed25519.PublicKey:
+		return PublicKeyDescription{Algorithm: "Ed25519", Size: 256}
+	}
+	return PublicKeyDescription{Algorithm: fmt.Sprintf("%T", pub)}
+}
+
+func colonHex(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for i, v := range b {
+		if i > 0 {
+			sb.WriteByte(':')
+		}
+		fmt.Fprintf(&sb, "%02X", v)
+	}
+	return sb.String()
+}
+
+// String returns the description in the text like "openssl x509 -text".
+func (d *Description) String() string {
+	var sb strings.Builder
+	_ = d.WriteText(&sb)
+	return sb.String()
+}
+
+// WriteText writes the description in the text like "openssl x509 -text" into w.
+func (d *Description) WriteText(w io.Writer) error {
+	var buf bytes.Buffer
+	line := func(indent int, format string, a ...interface{}) {
+		buf.WriteString(strings.Repeat("    ", indent))
+		fmt.Fprintf(&buf, format, a...)
+		buf.WriteByte('\n')
+	}
+
+	for i, c := range d.Certificates {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		line(0, "Certificate [%d]:", i)
+		line(1, "Version: %d", c.Version)
+		line(1, "Serial Number: %s", c.SerialNumber)
+		line(1, "Signature Algorithm: %s", c.SignatureAlgorithm)
+		line(1, "Issuer: %s", c.Issuer)
+		line(1, "Validity:")
+		line(2, "Not Before: %s", c.NotBefore.Format(time.RFC3339))
+		line(2, "Not After : %s", c.NotAfter.Format(time.RFC3339))
+		line(1, "Subject: %s", c.Subject)
+		line(1, "Subject Public Key Info:")
+		if c.PublicKey.Curve != "" {
+			line(2, "Public Key Algorithm: %s (%d bit, %s)", c.PublicKey.Algorithm, c.PublicKey.Size, c.PublicKey.Curve)
+		} else {
+			line(2, "Public Key Algorithm: %s (%d bit)", c.PublicKey.Algorithm, c.PublicKey.Size)
+		}
+		line(1, "X509v3 extensions:")
+		if len(c.KeyUsage) > 0 {
+			line(2, "Key Usage: %s", strings.Join(c.KeyUsage, ", "))
+		}
+		if len(c.ExtKeyUsage) > 0 {
+			line(2, "Extended Key Usage: %s", strings.Join(c.ExtKeyUsage, ", "))
+		}
+		if bc := c.BasicConstraints; bc != nil {
+			if bc.MaxPathLen != nil {
+				line(2, "Basic Constraints: CA:%s, pathlen:%d", strings.ToUpper(fmt.Sprint(bc.CA)), *bc.MaxPathLen)
+			} else {
+				line(2, "Basic Constraints: CA:%s", strings.ToUpper(fmt.Sprint(bc.CA)))
+			}
+		}
+		if c.SubjectKeyID != "" {
+			line(2, "Subject Key Identifier: %s", c.SubjectKeyID)
+		}
+		if c.AuthorityKeyID != "" {
+			line(2, "Authority Key Identifier: %s", c.AuthorityKeyID)
+		}
+		var names []string
+		for _, v := range c.DNSNames {
+			names = append(names, "DNS:"+v)
+		}
+		for _, v := range c.IPAddresses {
+			names = append(names, "IP Address:"+v)
+		}
+		for _, v := range c.EmailAddresses {
+			names = append(names, "email:"+v)
+		}
+		for _, v := range c.URIs {
+			names = append(names, "URI:"+v)
+		}
+		if len(names) > 0 {
+			line(2, "Subject Alternative Name: %s", strings.Join(names, ", "))
+		}
+		line(1, "Fingerprints:")
+		line(2, "SHA-1:   %s", c.SHA1Fingerprint)
+		line(2, "SHA-256: %s", c.SHA256Fingerprint)
+		line(1, "Chain: %s", c.Chain)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,94 @@
+package cert4now_test
+
+import (
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takumakei/go-cert4now"
+)
+
+func TestDescribe(t *testing.T) {
+	ca, err := cert4now.Generate(
+		cert4now.CommonName("Root CA"),
+		cert4now.KeyUsage(x509.KeyUsageCertSign|x509.KeyUsageCRLSign),
+		cert4now.ExtKeyUsage(),
+		cert4now.IsCA(true),
+		cert4now.ECDSA(elliptic.P384()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := cert4now.Generate(
+		cert4now.Authority(ca),
+		cert4now.CommonName("www.example.com"),
+		cert4now.Names("www.example.com", "192.0.2.1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := cert4now.Describe(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Certificates) != 2 {
+		t.Fatalf("want 2 certificates, got %d", len(d.Certificates))
+	}
+
+	leaf, root := d.Certificates[0], d.Certificates[1]
+	if diff := cmp.Diff([]string{"signed by next", "self-signed"}, []string{leaf.Chain, root.Chain}); diff != "" {
+		t.Fatalf("-want +got\n%s", diff)
+	}
+	if leaf.AuthorityKeyID != root.SubjectKeyID {
+		t.Fatalf("AKID %s != SKID %s", leaf.AuthorityKeyID, root.SubjectKeyID)
+	}
+	if diff := cmp.Diff(cert4now.PublicKeyDescription{Algorithm: "ECDSA", Size: 384, Curve: "P-384"}, root.PublicKey); diff != "" {
+		t.Fatalf("-want +got\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"serverAuth", "clientAuth"}, leaf.ExtKeyUsage); diff != "" {
+		t.Fatalf("-want +got\n%s", diff)
+	}
+
+	text := d.String()
+	for _, want := range []string{
+		"Subject: CN=www.example.com",
+		"Subject Alternative Name: DNS:www.example.com, IP Address:192.0.2.1",
+		"Key Usage: certSign, crlSign",
+		"Basic Constraints: CA:TRUE",
+		"Public Key Algorithm: RSA (2048 bit)",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q is missing in\n%s", want, text)
+		}
+	}
+
+	p, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back cert4now.Description
+	if err := json.Unmarshal(p, &back); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(*d, back); diff != "" {
+		t.Fatalf("-want +got\n%s", diff)
+	}
+}
+
+func TestDescribe_selfSignedLeaf(t *testing.T) {
+	cert, err := cert4now.Generate(cert4now.Ed25519())
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := cert4now.Describe(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Certificates[0].Chain; got != "self-signed" {
+		t.Fatalf("want self-signed, got %q", got)
+	}
+}
@@ -21,6 +21,7 @@ var (
 	serverHost         string
 	serverPort         int
 	insecureSkipVerify bool
+	localNames         bool
 )
 
 func main() {
@@ -31,6 +32,7 @@ func run() error {
 	flag.StringVar(&serverHost, "h", "127.0.0.1", "host")
 	flag.IntVar(&serverPort, "p", 8443, "port")
 	flag.BoolVar(&insecureSkipVerify, "i", false, "[CLIENT] insecureSkipVerify")
+	flag.BoolVar(&localNames, "l", false, "[SERVER] add the names and the addresses of the local host")
 	flag.Parse()
 	if arg0 := flag.Arg(0); arg0 != "" {
 		cmds := map[string]func() error{
@@ -58,10 +60,14 @@ func usage() error {
 }
 
 func server() error {
-	cert, err := cert4now.Generate(
+	options := []cert4now.Option{
 		cert4now.Names(serverHost),
 		cert4now.ECDSA(elliptic.P384()),
-	)
+	}
+	if localNames {
+		options = append(options, cert4now.LocalNames())
+	}
+	cert, err := cert4now.Generate(options...)
 	if err != nil {
 		return err
 	}
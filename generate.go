@@ -1,6 +1,7 @@
 package cert4now
 
 import (
+	"context"
 	"crypto"
 	"crypto/rand"
 	"crypto/sha1"
@@ -8,34 +9,69 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
+	"errors"
+	"math/big"
+	"time"
 )
 
+// ErrNoAuthority represents the certificate can not be self signed because of lacking the private key.
+var ErrNoAuthority = errors.New("authority is required to sign the certificate without the private key")
+
 // Generate generates a new certificate.
-func Generate(options ...Option) (cert tls.Certificate, err error) {
-	p := &param{
-		keyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
-		extKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
-	}
+func Generate(options ...Option) (tls.Certificate, error) {
+	return GenerateContext(context.Background(), options...)
+}
+
+// GenerateContext generates a new certificate, returning the error of ctx as soon as ctx is done
+// while generating the private key, signing the certificate or recording it into the inventory.
+func GenerateContext(ctx context.Context, options ...Option) (cert tls.Certificate, err error) {
+	p := newParam()
 	err = p.apply(options...)
 	if err != nil {
 		return
 	}
 
+	if p.cacheKey != "" {
+		if cached, ok := p.cache.get(p.cacheKey, p.cacheRelative, time.Now()); ok {
+			if p.lintMode == lintStrict {
+				var leaf *x509.Certificate
+				leaf, err = x509.ParseCertificate(cached.Certificate[0])
+				if err == nil {
+					err = p.lint(leaf)
+				}
+				if err != nil {
+					return
+				}
+			}
+			return cached, nil
+		}
+	}
+
 	var signer crypto.Signer
-	signer, err = p.genSigner()
-	if err != nil {
-		return
+	publicKey := p.publicKey
+	if publicKey == nil {
+		signer, err = p.newSignerContext(ctx)
+		if err != nil {
+			return
+		}
+		publicKey = signer.Public()
 	}
 
-	var skid []byte
-	skid, err = calculateSKID(signer.Public())
-	if err != nil {
-		return
+	skid := p.subjectKeyID
+	if skid == nil {
+		skid, err = calculateSKID(publicKey)
+		if err != nil {
+			return
+		}
 	}
 
 	var akid []byte
 	authorityKey := p.authorityKey
 	if authorityKey == nil {
+		if signer == nil {
+			err = ErrNoAuthority
+			return
+		}
 		authorityKey = signer
 	} else {
 		akid, err = calculateSKID(authorityKey.Public())
@@ -44,16 +80,38 @@ func Generate(options ...Option) (cert tls.Certificate, err error) {
 		}
 	}
 
+	issuerKeyID := akid
+	if p.authorityKey == nil {
+		issuerKeyID = skid
+	}
+	var serialNumber *big.Int
+	serialNumber, err = p.assignSerial(ctx, issuerKeyID)
+	if err != nil {
+		return
+	}
+
+	var signatureAlgorithm x509.SignatureAlgorithm
+	signatureAlgorithm, err = p.signatureAlgorithmFor(authorityKey.Public())
+	if err != nil {
+		return
+	}
+
 	template := &x509.Certificate{
-		SerialNumber: p.serialNumber,
-		Subject:      *p.subject,
-		NotBefore:    p.notBefore,
-		NotAfter:     p.notAfter,
-		KeyUsage:     p.keyUsage,
-		ExtKeyUsage:  p.extKeyUsage,
+		SignatureAlgorithm: signatureAlgorithm,
+
+		SerialNumber:       serialNumber,
+		Subject:            *p.subject,
+		RawSubject:         p.rawSubject,
+		NotBefore:          p.notBefore,
+		NotAfter:           p.notAfter,
+		KeyUsage:           p.keyUsageFor(publicKey),
+		ExtKeyUsage:        p.extKeyUsage,
+		UnknownExtKeyUsage: p.unknownExtKeyUsage,
 
 		BasicConstraintsValid: p.basicConstraintsValid,
 		IsCA:                  p.isCA,
+		MaxPathLen:            p.maxPathLen,
+		MaxPathLenZero:        p.maxPathLenZero,
 
 		SubjectKeyId:   skid,
 		AuthorityKeyId: akid,
@@ -61,6 +119,20 @@ func Generate(options ...Option) (cert tls.Certificate, err error) {
 		DNSNames:       p.dnsNames,
 		EmailAddresses: p.emailAddresses,
 		IPAddresses:    p.ipAddresses,
+		URIs:           p.uris,
+
+		ExtraExtensions: p.extraExtensions,
+	}
+
+	if !p.isCA {
+		template.MaxPathLen, template.MaxPathLenZero = 0, false
+	}
+
+	lintee := *template
+	lintee.PublicKey = publicKey
+	err = p.lint(&lintee)
+	if err != nil {
+		return
 	}
 
 	authority := p.authority
@@ -69,18 +141,34 @@ func Generate(options ...Option) (cert tls.Certificate, err error) {
 	}
 
 	var der []byte
-	der, err = x509.CreateCertificate(rand.Reader, template, authority, signer.Public(), authorityKey)
+	der, err = x509.CreateCertificate(rand.Reader, template, authority, publicKey, withContext(ctx, authorityKey))
 	if err != nil {
 		return
 	}
 
 	cert.Certificate = [][]byte{der}
-	cert.PrivateKey = signer
+	if signer != nil {
+		cert.PrivateKey = signer
+	}
 
 	if len(p.chain) > 0 {
 		cert.Certificate = append(cert.Certificate, p.chain...)
 	}
 
+	if p.cacheKey != "" {
+		// The cache is only to save the time, failing to store into it is not the failure of Generate.
+		_ = p.cache.put(p.cacheKey, cert)
+	}
+
+	if p.store != nil {
+		var leaf *x509.Certificate
+		leaf, err = x509.ParseCertificate(der)
+		if err != nil {
+			return
+		}
+		err = p.store.Put(ctx, newIssuedCertificate(leaf, p.metadata))
+	}
+
 	return
 }
 
@@ -2,14 +2,19 @@ package cert4now_test
 
 import (
 	"bytes"
+	"crypto/elliptic"
 	"crypto/tls"
 	"crypto/x509"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/takumakei/go-cert4now"
 )
 
+// ignoreLeaf ignores Leaf, which tls.X509KeyPair populates since Go 1.23 while Generate does not.
+var ignoreLeaf = cmpopts.IgnoreFields(tls.Certificate{}, "Leaf")
+
 func TestGenerate(t *testing.T) {
 	cert, err := cert4now.Generate()
 	if err != nil {
@@ -26,7 +31,7 @@ func TestGenerate(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if diff := cmp.Diff(cert, load); diff != "" {
+	if diff := cmp.Diff(cert, load, ignoreLeaf); diff != "" {
 		t.Fatalf("-want +got\n%s", diff)
 	}
 }
@@ -86,3 +91,43 @@ func TestGenerate_chain(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestGenerate_keyUsage(t *testing.T) {
+	const (
+		ds   = x509.KeyUsageDigitalSignature
+		ke   = x509.KeyUsageKeyEncipherment
+		sign = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	)
+	cases := []struct {
+		Name    string
+		Options []cert4now.Option
+		Want    x509.KeyUsage
+	}{
+		{"rsa", nil, ds | ke},
+		{"ecdsa", []cert4now.Option{cert4now.ECDSA(elliptic.P256())}, ds},
+		{"ed25519", []cert4now.Option{cert4now.Ed25519()}, ds},
+		{"ca", []cert4now.Option{cert4now.IsCA(true)}, ds | sign},
+		{"ecdsa ca", []cert4now.Option{cert4now.IsCA(true), cert4now.ECDSA(elliptic.P256())}, ds | sign},
+		{"profile", []cert4now.Option{cert4now.EmailProtectionProfile(), cert4now.Ed25519()}, ds},
+		{"explicit", []cert4now.Option{cert4now.ECDSA(elliptic.P256()), cert4now.KeyUsage(ds | ke)}, ds | ke},
+	}
+	for _, c := range cases {
+		cert, err := cert4now.Generate(append(c.Options, cert4now.Names("localhost"))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := parseLeaf(t, cert).KeyUsage; got != c.Want {
+			t.Errorf("%s: want %v, got %v", c.Name, cert4now.KeyUsageNames(c.Want), cert4now.KeyUsageNames(got))
+		}
+	}
+}
+
+// parseLeaf returns the leaf certificate of cert parsed.
+func parseLeaf(t testing.TB, cert tls.Certificate) *x509.Certificate {
+	t.Helper()
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf
+}
@@ -6,4 +6,6 @@ require (
 	github.com/google/go-cmp v0.5.5
 	github.com/oklog/run v1.1.0
 	github.com/takumakei/go-exit v0.0.0-20210429095029-8c3e71abac7f
+	golang.org/x/net v0.0.0-20210510120150-4163338589ed
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,246 @@
+package cert4now
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IssuedCertificate is the record of an issued certificate.
+type IssuedCertificate struct {
+	SerialNumber   string            `json:"serial"`
+	Issuer         string            `json:"issuer"`
+	Subject        string            `json:"subject"`
+	CommonName     string            `json:"commonName,omitempty"`
+	DNSNames       []string          `json:"dnsNames,omitempty"`
+	IPAddresses    []string          `json:"ipAddresses,omitempty"`
+	EmailAddresses []string          `json:"emailAddresses,omitempty"`
+	NotBefore      time.Time         `json:"notBefore"`
+	NotAfter       time.Time         `json:"notAfter"`
+	Fingerprint    string            `json:"sha256"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	IssuedAt       time.Time         `json:"issuedAt"`
+}
+
+// Query selects issued certificates. The zero value of each field matches any.
+type Query struct {
+	// SerialNumber is the serial number in hex.
+	SerialNumber string
+
+	// Name is a pattern of path.Match matched against the common name and the subject alternative names,
+	// for example "*.staging" or "192.0.2.*".
+	Name string
+
+	// ExpiresAfter and ExpiresBefore select the certificates of NotAfter within the window.
+	ExpiresAfter  time.Time
+	ExpiresBefore time.Time
+}
+
+// Store is the inventory of issued certificates.
+type Store interface {
+	// Put records the issued certificate.
+	Put(ctx context.Context, v IssuedCertificate) error
+
+	// Find returns the issued certificates matching q in the order of Put.
+	Find(ctx context.Context, q Query) ([]IssuedCertificate, error)
+}
+
+// Matches reports whether v matches q.
+func (q Query) Matches(v IssuedCertificate) bool {
+	if q.SerialNumber != "" && !strings.EqualFold(strings.TrimLeft(q.SerialNumber, "0"), strings.TrimLeft(v.SerialNumber, "0")) {
+		return false
+	}
+	if !q.ExpiresAfter.IsZero() && v.NotAfter.Before(q.ExpiresAfter) {
+		return false
+	}
+	if !q.ExpiresBefore.IsZero() && !v.NotAfter.Before(q.ExpiresBefore) {
+		return false
+	}
+	if q.Name != "" {
+		names := append([]string{v.CommonName}, v.DNSNames...)
+		names = append(names, v.IPAddresses...)
+		names = append(names, v.EmailAddresses...)
+		for _, name := range names {
+			if ok, _ := path.Match(strings.ToLower(q.Name), strings.ToLower(name)); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// MemoryStore is Store keeping the records in memory.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records []IssuedCertificate
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore returns a new empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Put records the issued certificate.
+func (s *MemoryStore) Put(ctx context.Context, v IssuedCertificate) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, v)
+	return nil
+}
+
+// Find returns the issued certificates matching q.
+func (s *MemoryStore) Find(ctx context.Context, q Query) ([]IssuedCertificate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var found []IssuedCertificate
+	for _, v := range s.records {
+		if q.Matches(v) {
+			found = append(found, v)
+		}
+	}
+	return found, nil
+}
+
+// FileStore is Store keeping the records in a file of JSON lines.
+type FileStore struct {
+	filename string
+	mu       sync.Mutex
+}
+
+var _ Store = (*FileStore)(nil)
+
+// NewFileStore returns FileStore keeping the records in the file of filename.
+// The file is created on the first Put.
+func NewFileStore(filename string) *FileStore {
+	return &FileStore{filename: filename}
+}
+
+// Put appends the issued certificate to the file.
+func (s *FileStore) Put(ctx context.Context, v IssuedCertificate) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return appendJSONLine(s.filename, v)
+}
+
+// Find reads the file, then returns the issued certificates matching q.
+func (s *FileStore) Find(ctx context.Context, q Query) ([]IssuedCertificate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var found []IssuedCertificate
+	err := readJSONLines(s.filename, func(p []byte) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var v IssuedCertificate
+		if err := json.Unmarshal(p, &v); err != nil {
+			return err
+		}
+		if q.Matches(v) {
+			found = append(found, v)
+		}
+		return nil
+	})
+	return found, err
+}
+
+// Inventory returns an option of recording the generated certificate into store.
+func Inventory(store Store) Option {
+	return func(p *param) {
+		p.store = store
+	}
+}
+
+// Metadata returns an option of setting the metadata recorded into the inventory,
+// such as the requester of the certificate.
+func Metadata(key, value string) Option {
+	return func(p *param) {
+		if p.metadata == nil {
+			p.metadata = make(map[string]string)
+		}
+		p.metadata[key] = value
+	}
+}
+
+func newIssuedCertificate(c *x509.Certificate, metadata map[string]string) IssuedCertificate {
+	sum := sha256.Sum256(c.Raw)
+	v := IssuedCertificate{
+		SerialNumber:   c.SerialNumber.Text(16),
+		Issuer:         c.Issuer.String(),
+		Subject:        c.Subject.String(),
+		CommonName:     c.Subject.CommonName,
+		DNSNames:       c.DNSNames,
+		EmailAddresses: c.EmailAddresses,
+		NotBefore:      c.NotBefore,
+		NotAfter:       c.NotAfter,
+		Fingerprint:    hex.EncodeToString(sum[:]),
+		Metadata:       metadata,
+		IssuedAt:       time.Now().UTC(),
+	}
+	for _, ip := range c.IPAddresses {
+		v.IPAddresses = append(v.IPAddresses, ip.String())
+	}
+	return v
+}
+
+// appendJSONLine appends v in JSON as a line to the file of filename.
+func appendJSONLine(filename string, v interface{}) error {
+	p, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(p, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func readJSONLines(filename string, fn func([]byte) error) error {
+	f, err := os.Open(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		if len(bytes.TrimSpace(s.Bytes())) == 0 {
+			continue
+		}
+		if err := fn(s.Bytes()); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
@@ -0,0 +1,77 @@
+package cert4now_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func TestInventory(t *testing.T) {
+	stores := map[string]cert4now.Store{
+		"memory": cert4now.NewMemoryStore(),
+		"file":   cert4now.NewFileStore(filepath.Join(t.TempDir(), "index.jsonl")),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			testInventory(t, store)
+		})
+	}
+}
+
+func testInventory(t *testing.T, store cert4now.Store) {
+	ctx := context.Background()
+	now := time.Now()
+
+	issue := func(days int, names ...string) {
+		t.Helper()
+		_, err := cert4now.Generate(
+			cert4now.Names(names...),
+			cert4now.NotBefore(now),
+			cert4now.AddDate(0, 0, days),
+			cert4now.Inventory(store),
+			cert4now.Metadata("requester", "alice"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	issue(10, "api.staging", "127.0.0.1")
+	issue(30, "web.staging")
+	issue(90, "www.example.com")
+
+	all, err := store.Find(ctx, cert4now.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("want 3 records, got %d", len(all))
+	}
+	if all[0].Metadata["requester"] != "alice" || all[0].IPAddresses[0] != "127.0.0.1" {
+		t.Fatalf("unexpected record %+v", all[0])
+	}
+
+	cases := []struct {
+		Query cert4now.Query
+		Want  int
+	}{
+		{cert4now.Query{Name: "*.staging"}, 2},
+		{cert4now.Query{Name: "127.0.0.*"}, 1},
+		{cert4now.Query{Name: "*.example.org"}, 0},
+		{cert4now.Query{SerialNumber: all[2].SerialNumber}, 1},
+		{cert4now.Query{ExpiresBefore: now.AddDate(0, 0, 31)}, 2},
+		{cert4now.Query{ExpiresAfter: now.AddDate(0, 0, 11), ExpiresBefore: now.AddDate(0, 0, 31)}, 1},
+		{cert4now.Query{Name: "*.staging", ExpiresAfter: now.AddDate(0, 0, 11)}, 1},
+	}
+	for i, c := range cases {
+		found, err := store.Find(ctx, c.Query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != c.Want {
+			t.Errorf("[%d] want %d records, got %d", i, c.Want, len(found))
+		}
+	}
+}
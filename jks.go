@@ -0,0 +1,310 @@
+package cert4now
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// JKSOption represents an option for writing a Java KeyStore.
+type JKSOption func(*jksParam)
+
+type jksParam struct {
+	alias       string
+	password    string
+	keyPassword *string
+	timestamp   time.Time
+}
+
+// DefaultJKSPassword is the password used when JKSPassword is not given.
+// It is the well known default password of the JDK.
+const DefaultJKSPassword = "changeit"
+
+// JKSAlias returns an option of setting the alias of the entry.
+// When writing several certificates into a truststore, the alias is suffixed
+// with "-2", "-3", ... to keep each alias unique.
+func JKSAlias(alias string) JKSOption {
+	return func(p *jksParam) {
+		p.alias = alias
+	}
+}
+
+// JKSPassword returns an option of setting the password protecting the integrity of the keystore.
+func JKSPassword(password string) JKSOption {
+	return func(p *jksParam) {
+		p.password = password
+	}
+}
+
+// JKSKeyPassword returns an option of setting the password protecting the private key.
+// The store password is used when this option is not given.
+func JKSKeyPassword(password string) JKSOption {
+	return func(p *jksParam) {
+		p.keyPassword = &password
+	}
+}
+
+// JKSTimestamp returns an option of setting the creation date of the entries.
+func JKSTimestamp(t time.Time) JKSOption {
+	return func(p *jksParam) {
+		p.timestamp = t
+	}
+}
+
+func newJKSParam(options []JKSOption) *jksParam {
+	p := &jksParam{password: DefaultJKSPassword}
+	for _, option := range options {
+		option(p)
+	}
+	if p.timestamp.IsZero() {
+		p.timestamp = time.Now()
+	}
+	return p
+}
+
+// WriteKeyStore writes the certificate, its chain and the private key into w
+// as a PrivateKeyEntry of a Java KeyStore.
+func WriteKeyStore(w io.Writer, cert tls.Certificate, options ...JKSOption) error {
+	p, err := EncodeKeyStore(cert, options...)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(p)
+	return err
+}
+
+// WriteKeyStoreFile writes the certificate into the file of filename as a Java KeyStore.
+func WriteKeyStoreFile(filename string, cert tls.Certificate, perm fs.FileMode, options ...JKSOption) error {
+	p, err := EncodeKeyStore(cert, options...)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, p, perm)
+}
+
+// EncodeKeyStore encodes the certificate of cert into a Java KeyStore.
+func EncodeKeyStore(cert tls.Certificate, options ...JKSOption) ([]byte, error) {
+	if len(cert.Certificate) == 0 {
+		return nil, errors.New("no certificate to write into keystore")
+	}
+	p := newJKSParam(options)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	keyPassword := p.password
+	if p.keyPassword != nil {
+		keyPassword = *p.keyPassword
+	}
+	protected, err := jksProtectKey(pkcs8, keyPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	alias := p.alias
+	if alias == "" {
+		alias = jksDefaultAlias(cert.Certificate[0], "key")
+	}
+
+	var e jksEncoder
+	e.header(1)
+	e.uint32(1)
+	if err := e.utf(strings.ToLower(alias)); err != nil {
+		return nil, err
+	}
+	e.timestamp(p.timestamp)
+	e.bytes(protected)
+	e.uint32(uint32(len(cert.Certificate)))
+	for _, der := range cert.Certificate {
+		e.certificate(der)
+	}
+	return e.finish(p.password), nil
+}
+
+// WriteTrustStore writes the leaf certificate of each of certs into w
+// as TrustedCertificateEntries of a Java KeyStore.
+func WriteTrustStore(w io.Writer, certs []tls.Certificate, options ...JKSOption) error {
+	p, err := EncodeTrustStore(certs, options...)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(p)
+	return err
+}
+
+// WriteTrustStoreFile writes the certificates into the file of filename as a Java KeyStore.
+func WriteTrustStoreFile(filename string, certs []tls.Certificate, perm fs.FileMode, options ...JKSOption) error {
+	p, err := EncodeTrustStore(certs, options...)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, p, perm)
+}
+
+// EncodeTrustStore encodes the leaf certificate of each of certs into a Java KeyStore.
+func EncodeTrustStore(certs []tls.Certificate, options ...JKSOption) ([]byte, error) {
+	p := newJKSParam(options)
+
+	var e jksEncoder
+	e.header(uint32(len(certs)))
+	used := make(map[string]bool)
+	for _, cert := range certs {
+		if len(cert.Certificate) == 0 {
+			return nil, errors.New("no certificate to write into truststore")
+		}
+		alias := p.alias
+		if alias == "" {
+			alias = jksDefaultAlias(cert.Certificate[0], "ca")
+		}
+		alias = strings.ToLower(alias)
+		unique := alias
+		for i := 2; used[unique]; i++ {
+			unique = fmt.Sprintf("%s-%d", alias, i)
+		}
+		used[unique] = true
+
+		e.uint32(2)
+		if err := e.utf(unique); err != nil {
+			return nil, err
+		}
+		e.timestamp(p.timestamp)
+		e.certificate(cert.Certificate[0])
+	}
+	return e.finish(p.password), nil
+}
+
+func jksDefaultAlias(der []byte, fallback string) string {
+	c, err := x509.ParseCertificate(der)
+	if err != nil || c.Subject.CommonName == "" {
+		return fallback
+	}
+	return c.Subject.CommonName
+}
+
+// oidJKSKeyProtector is the algorithm of the proprietary key protection of the JDK.
+var oidJKSKeyProtector = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 42, 2, 17, 1, 1}
+
+// jksProtectKey encrypts the PKCS#8 encoded private key in the way
+// sun.security.provider.KeyProtector does.
+func jksProtectKey(plain []byte, password string) ([]byte, error) {
+	passwd := jksPassword(password)
+
+	salt := make([]byte, sha1.Size)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	encrypted := make([]byte, 0, sha1.Size+len(plain)+sha1.Size)
+	encrypted = append(encrypted, salt...)
+	digest := salt
+	for i := 0; i < len(plain); i += sha1.Size {
+		h := sha1.New()
+		h.Write(passwd)
+		h.Write(digest)
+		digest = h.Sum(nil)
+		for j := 0; j < sha1.Size && i+j < len(plain); j++ {
+			encrypted = append(encrypted, plain[i+j]^digest[j])
+		}
+	}
+	h := sha1.New()
+	h.Write(passwd)
+	h.Write(plain)
+	encrypted = h.Sum(encrypted)
+
+	return asn1.Marshal(struct {
+		Algorithm     pkix.AlgorithmIdentifier
+		EncryptedData []byte
+	}{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidJKSKeyProtector,
+			Parameters: asn1.NullRawValue,
+		},
+		EncryptedData: encrypted,
+	})
+}
+
+// jksPassword returns the password in the byte representation the JDK uses, that is UTF-16BE.
+func jksPassword(password string) []byte {
+	u := utf16.Encode([]rune(password))
+	b := make([]byte, 2*len(u))
+	for i, v := range u {
+		binary.BigEndian.PutUint16(b[2*i:], v)
+	}
+	return b
+}
+
+type jksEncoder struct {
+	buf bytes.Buffer
+}
+
+func (e *jksEncoder) header(count uint32) {
+	e.uint32(0xfeedfeed)
+	e.uint32(2)
+	e.uint32(count)
+}
+
+func (e *jksEncoder) uint32(v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	e.buf.Write(b[:])
+}
+
+func (e *jksEncoder) timestamp(t time.Time) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(t.UnixNano()/int64(time.Millisecond)))
+	e.buf.Write(b[:])
+}
+
+func (e *jksEncoder) bytes(p []byte) {
+	e.uint32(uint32(len(p)))
+	e.buf.Write(p)
+}
+
+// utf writes s in the modified UTF-8 of java.io.DataOutput.
+func (e *jksEncoder) utf(s string) error {
+	var b []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		switch {
+		case u >= 0x0001 && u <= 0x007f:
+			b = append(b, byte(u))
+		case u <= 0x07ff:
+			b = append(b, byte(0xc0|(u>>6)&0x1f), byte(0x80|u&0x3f))
+		default:
+			b = append(b, byte(0xe0|(u>>12)&0x0f), byte(0x80|(u>>6)&0x3f), byte(0x80|u&0x3f))
+		}
+	}
+	if len(b) > 0xffff {
+		return errors.New("alias is too long")
+	}
+	e.buf.Write([]byte{byte(len(b) >> 8), byte(len(b))})
+	e.buf.Write(b)
+	return nil
+}
+
+func (e *jksEncoder) certificate(der []byte) {
+	_ = e.utf("X.509")
+	e.bytes(der)
+}
+
+// finish appends the keyed digest protecting the integrity of the keystore.
+func (e *jksEncoder) finish(password string) []byte {
+	h := sha1.New()
+	h.Write(jksPassword(password))
+	h.Write([]byte("Mighty Aphrodite"))
+	h.Write(e.buf.Bytes())
+	return h.Sum(e.buf.Bytes())
+}
@@ -0,0 +1,168 @@
+package cert4now_test
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takumakei/go-cert4now"
+)
+
+type jksEntry struct {
+	Tag   uint32
+	Alias string
+	Key   []byte
+	Certs [][]byte
+}
+
+// readJKS is a minimal reader of the Java KeyStore, enough to examine what cert4now writes.
+func readJKS(t *testing.T, p []byte, password string) []jksEntry {
+	t.Helper()
+
+	var passwd []byte
+	for _, u := range utf16.Encode([]rune(password)) {
+		passwd = append(passwd, byte(u>>8), byte(u))
+	}
+	body, sum := p[:len(p)-sha1.Size], p[len(p)-sha1.Size:]
+	h := sha1.New()
+	h.Write(passwd)
+	h.Write([]byte("Mighty Aphrodite"))
+	h.Write(body)
+	if !bytes.Equal(h.Sum(nil), sum) {
+		t.Fatal("keystore digest mismatch")
+	}
+
+	r := bytes.NewReader(body)
+	u32 := func() uint32 {
+		var v uint32
+		if err := binary.Read(r, binary.BigEndian, &v); err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	utf := func() string {
+		var n uint16
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			t.Fatal(err)
+		}
+		b := make([]byte, n)
+		r.Read(b)
+		return string(b)
+	}
+	blob := func() []byte {
+		b := make([]byte, u32())
+		r.Read(b)
+		return b
+	}
+
+	if magic := u32(); magic != 0xfeedfeed {
+		t.Fatalf("magic %x", magic)
+	}
+	if version := u32(); version != 2 {
+		t.Fatalf("version %d", version)
+	}
+	entries := make([]jksEntry, u32())
+	for i := range entries {
+		e := &entries[i]
+		e.Tag = u32()
+		e.Alias = utf()
+		r.Seek(8, 1)
+		switch e.Tag {
+		case 1:
+			var info struct {
+				Algorithm     asn1.RawValue
+				EncryptedData []byte
+			}
+			if _, err := asn1.Unmarshal(blob(), &info); err != nil {
+				t.Fatal(err)
+			}
+			enc := info.EncryptedData
+			salt, data, check := enc[:20], enc[20:len(enc)-20], enc[len(enc)-20:]
+			digest := salt
+			for j := 0; j < len(data); j += 20 {
+				d := sha1.Sum(append(append([]byte(nil), passwd...), digest...))
+				digest = d[:]
+				for k := 0; k < 20 && j+k < len(data); k++ {
+					e.Key = append(e.Key, data[j+k]^digest[k])
+				}
+			}
+			if c := sha1.Sum(append(append([]byte(nil), passwd...), e.Key...)); !bytes.Equal(c[:], check) {
+				t.Fatal("key check mismatch")
+			}
+			for n := u32(); n > 0; n-- {
+				if typ := utf(); typ != "X.509" {
+					t.Fatalf("certificate type %q", typ)
+				}
+				e.Certs = append(e.Certs, blob())
+			}
+		case 2:
+			if typ := utf(); typ != "X.509" {
+				t.Fatalf("certificate type %q", typ)
+			}
+			e.Certs = append(e.Certs, blob())
+		default:
+			t.Fatalf("unknown tag %d", e.Tag)
+		}
+	}
+	if r.Len() != 0 {
+		t.Fatalf("%d bytes left", r.Len())
+	}
+	return entries
+}
+
+func TestEncodeKeyStore(t *testing.T) {
+	ca, err := cert4now.Generate(cert4now.CommonName("Root CA"), cert4now.IsCA(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := cert4now.Generate(cert4now.Authority(ca), cert4now.CommonName("Leaf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := cert4now.EncodeKeyStore(cert, cert4now.JKSAlias("Server"), cert4now.JKSPassword("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := readJKS(t, p, "secret")
+
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []jksEntry{{Tag: 1, Alias: "server", Key: key, Certs: cert.Certificate}}
+	if diff := cmp.Diff(want, entries); diff != "" {
+		t.Fatalf("-want +got\n%s", diff)
+	}
+}
+
+func TestEncodeTrustStore(t *testing.T) {
+	a, err := cert4now.Generate(cert4now.CommonName("Root CA"), cert4now.IsCA(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := cert4now.Generate(cert4now.CommonName("Root CA"), cert4now.IsCA(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := cert4now.EncodeTrustStore([]tls.Certificate{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := readJKS(t, p, cert4now.DefaultJKSPassword)
+
+	want := []jksEntry{
+		{Tag: 2, Alias: "root ca", Certs: a.Certificate[:1]},
+		{Tag: 2, Alias: "root ca-2", Certs: b.Certificate[:1]},
+	}
+	if diff := cmp.Diff(want, entries); diff != "" {
+		t.Fatalf("-want +got\n%s", diff)
+	}
+}
@@ -0,0 +1,137 @@
+package cert4now
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+)
+
+// JSONWebKey represents the public part of a JSON Web Key of RFC 7517.
+type JSONWebKey struct {
+	KeyType   string   `json:"kty"`
+	KeyID     string   `json:"kid,omitempty"`
+	Use       string   `json:"use,omitempty"`
+	Algorithm string   `json:"alg,omitempty"`
+	Curve     string   `json:"crv,omitempty"`
+	X         string   `json:"x,omitempty"`
+	Y         string   `json:"y,omitempty"`
+	N         string   `json:"n,omitempty"`
+	E         string   `json:"e,omitempty"`
+	X5C       []string `json:"x5c,omitempty"`
+	X5TS256   string   `json:"x5t#S256,omitempty"`
+}
+
+// JSONWebKeySet represents a JWK Set of RFC 7517.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// ErrUnsupportedJWK represents the public key can not be represented as a JSON Web Key.
+var ErrUnsupportedJWK = errors.New("public key is not supported by JWK")
+
+// JWK converts the certificate into a JSON Web Key for signature verification.
+// The kid is the base64url encoded subject key identifier of the certificate, the x5c is the chain of cert,
+// and the x5t#S256 is the SHA-256 thumbprint of the leaf certificate.
+// The alg follows the key, not the signature algorithm of the certificate signed by the authority.
+func JWK(cert tls.Certificate) (JSONWebKey, error) {
+	if len(cert.Certificate) == 0 {
+		return JSONWebKey{}, errors.New("no certificate to convert into JWK")
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return JSONWebKey{}, err
+	}
+
+	skid := leaf.SubjectKeyId
+	if len(skid) == 0 {
+		skid, err = calculateSKID(leaf.PublicKey)
+		if err != nil {
+			return JSONWebKey{}, err
+		}
+	}
+	thumbprint := sha256.Sum256(leaf.Raw)
+
+	jwk := JSONWebKey{
+		KeyID:   base64.RawURLEncoding.EncodeToString(skid),
+		Use:     "sig",
+		X5TS256: base64.RawURLEncoding.EncodeToString(thumbprint[:]),
+	}
+	for _, der := range cert.Certificate {
+		jwk.X5C = append(jwk.X5C, base64.StdEncoding.EncodeToString(der))
+	}
+
+	switch k := leaf.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.Algorithm = "RS256"
+		jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+
+	case *ecdsa.PublicKey:
+		jwk.KeyType = "EC"
+		switch k.Curve {
+		case elliptic.P256():
+			jwk.Curve, jwk.Algorithm = "P-256", "ES256"
+		case elliptic.P384():
+			jwk.Curve, jwk.Algorithm = "P-384", "ES384"
+		case elliptic.P521():
+			jwk.Curve, jwk.Algorithm = "P-521", "ES512"
+		default:
+			return JSONWebKey{}, ErrUnsupportedJWK
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		jwk.X = base64.RawURLEncoding.EncodeToString(fixedBytes(k.X, size))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(fixedBytes(k.Y, size))
+
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve, jwk.Algorithm = "Ed25519", "EdDSA"
+		jwk.X = base64.RawURLEncoding.EncodeToString(k)
+
+	default:
+		return JSONWebKey{}, ErrUnsupportedJWK
+	}
+
+	return jwk, nil
+}
+
+// JWKS converts the certificates into a JWK Set.
+func JWKS(certs ...tls.Certificate) (JSONWebKeySet, error) {
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(certs))}
+	for _, cert := range certs {
+		jwk, err := JWK(cert)
+		if err != nil {
+			return JSONWebKeySet{}, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
+
+// WriteJWKS writes the JWK Set of the certificates into w in JSON.
+func WriteJWKS(w io.Writer, certs ...tls.Certificate) error {
+	set, err := JWKS(certs...)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(set)
+}
+
+func fixedBytes(v *big.Int, size int) []byte {
+	b := v.Bytes()
+	if len(b) >= size {
+		return b
+	}
+	p := make([]byte, size)
+	copy(p[size-len(b):], b)
+	return p
+}
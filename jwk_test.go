@@ -0,0 +1,146 @@
+package cert4now_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func TestJWK(t *testing.T) {
+	ca, err := cert4now.Generate(cert4now.CommonName("Root CA"), cert4now.IsCA(true), cert4now.ECDSA(elliptic.P384()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := cert4now.Generate(cert4now.Authority(ca), cert4now.CommonName("signer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwk, err := cert4now.JWK(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jwk.KeyType != "RSA" || jwk.Algorithm != "RS256" {
+		t.Fatalf("unexpected kty %q alg %q", jwk.KeyType, jwk.Algorithm)
+	}
+	if want := base64.RawURLEncoding.EncodeToString(parseLeaf(t, cert).SubjectKeyId); jwk.KeyID != want {
+		t.Fatalf("kid want %q, got %q", want, jwk.KeyID)
+	}
+	if sum := sha256.Sum256(cert.Certificate[0]); jwk.X5TS256 != base64.RawURLEncoding.EncodeToString(sum[:]) {
+		t.Fatalf("unexpected x5t#S256 %q", jwk.X5TS256)
+	}
+	if len(jwk.X5C) != 2 {
+		t.Fatalf("want x5c of 2 certificates, got %d", len(jwk.X5C))
+	}
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub := cert.PrivateKey.(*rsa.PrivateKey).PublicKey; new(big.Int).SetBytes(n).Cmp(pub.N) != 0 || jwk.E != "AQAB" {
+		t.Fatal("modulus or exponent mismatch")
+	}
+
+	p, err := json.Marshal(jwk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(p, &m); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["x5t#S256"]; !ok {
+		t.Fatalf("x5t#S256 is missing in %s", p)
+	}
+}
+
+func TestJWK_certificate(t *testing.T) {
+	ca, err := cert4now.Generate(cert4now.CommonName("Root CA"), cert4now.IsCA(true), cert4now.RSA(2048))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := cert4now.Generate(cert4now.Authority(ca), cert4now.RSAPSS(), cert4now.SignatureHash(crypto.SHA384))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk, err := cert4now.JWK(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The certificate signed with RSA-PSS says nothing about how the key signs JWT.
+	if jwk.Algorithm != "RS256" {
+		t.Fatalf("want RS256, got %q", jwk.Algorithm)
+	}
+
+	// The kid is the subject key identifier of the certificate, even if not computed by cert4now.
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		SubjectKeyId: []byte{1, 2, 3, 4},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk, err = cert4now.JWK(tls.Certificate{Certificate: [][]byte{der}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jwk.KeyID != "AQIDBA" || jwk.Algorithm != "RS256" {
+		t.Fatalf("unexpected kid %q alg %q", jwk.KeyID, jwk.Algorithm)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	ec, err := cert4now.Generate(cert4now.ECDSA(elliptic.P521()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed, err := cert4now.Generate(cert4now.Signer(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := cert4now.JWKS(ec, ed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Keys) != 2 {
+		t.Fatalf("want 2 keys, got %d", len(set.Keys))
+	}
+
+	k := set.Keys[0]
+	if k.KeyType != "EC" || k.Curve != "P-521" || k.Algorithm != "ES512" {
+		t.Fatalf("unexpected %+v", k)
+	}
+	x, _ := base64.RawURLEncoding.DecodeString(k.X)
+	if len(x) != 66 || new(big.Int).SetBytes(x).Cmp(ec.PrivateKey.(*ecdsa.PrivateKey).X) != 0 {
+		t.Fatal("x coordinate mismatch")
+	}
+
+	k = set.Keys[1]
+	if k.KeyType != "OKP" || k.Curve != "Ed25519" || k.Algorithm != "EdDSA" {
+		t.Fatalf("unexpected %+v", k)
+	}
+}
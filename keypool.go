@@ -0,0 +1,165 @@
+package cert4now
+
+import (
+	"context"
+	"crypto"
+	"sync"
+	"sync/atomic"
+)
+
+// KeyPool generates private keys in the background, then Generate draws them from it
+// instead of generating them on demand.
+type KeyPool struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	queues map[keyType]chan crypto.Signer
+
+	hits      int64
+	misses    int64
+	generated int64
+}
+
+// KeyPoolStats is the metrics of KeyPool.
+type KeyPoolStats struct {
+	// Hits is the number of the keys drawn from the pool.
+	Hits int64
+
+	// Misses is the number of the keys generated on demand, since the pool had none of the type.
+	Misses int64
+
+	// Generated is the number of the keys generated in the background.
+	Generated int64
+}
+
+// NewKeyPool returns a new KeyPool keeping up to size keys for each type of the keys.
+// The types of the keys are given by the options RSA, ECDSA and Ed25519, for example
+//
+//	NewKeyPool(ctx, 8, RSA(2048), RSA(4096), ECDSA(elliptic.P256()))
+//
+// The pool stops generating keys when ctx is done or Close is called.
+func NewKeyPool(ctx context.Context, size int, keys ...Option) (*KeyPool, error) {
+	if size < 1 {
+		size = 1
+	}
+	type generator struct {
+		keyType keyType
+		gen     func() (crypto.Signer, error)
+	}
+	var generators []generator
+	var errs Errors
+	for _, option := range keys {
+		p := &param{}
+		option(p)
+		if p.genSigner == nil || p.fixedKey {
+			continue
+		}
+		if !p.keyType.supported() {
+			errs = append(errs, &UnsupportedKeyError{Key: p.keyType.String()})
+			continue
+		}
+		generators = append(generators, generator{keyType: p.keyType, gen: p.genSigner})
+	}
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	kp := &KeyPool{
+		ctx:    ctx,
+		cancel: cancel,
+		queues: make(map[keyType]chan crypto.Signer),
+	}
+	for _, g := range generators {
+		if _, ok := kp.queues[g.keyType]; ok {
+			continue
+		}
+		queue := make(chan crypto.Signer, size)
+		kp.queues[g.keyType] = queue
+		kp.wg.Add(1)
+		go kp.fill(queue, g.gen)
+	}
+	return kp, nil
+}
+
+func (kp *KeyPool) fill(queue chan<- crypto.Signer, gen func() (crypto.Signer, error)) {
+	defer kp.wg.Done()
+	for kp.ctx.Err() == nil {
+		key, err := gen()
+		if err != nil {
+			return
+		}
+		atomic.AddInt64(&kp.generated, 1)
+		select {
+		case queue <- key:
+		case <-kp.ctx.Done():
+		}
+	}
+}
+
+// get draws a key of the type from the pool without blocking.
+func (kp *KeyPool) get(t keyType) (crypto.Signer, bool) {
+	select {
+	case key := <-kp.queues[t]:
+		atomic.AddInt64(&kp.hits, 1)
+		return key, true
+	default:
+		atomic.AddInt64(&kp.misses, 1)
+		return nil, false
+	}
+}
+
+// Stats returns the metrics of kp.
+func (kp *KeyPool) Stats() KeyPoolStats {
+	return KeyPoolStats{
+		Hits:      atomic.LoadInt64(&kp.hits),
+		Misses:    atomic.LoadInt64(&kp.misses),
+		Generated: atomic.LoadInt64(&kp.generated),
+	}
+}
+
+// Close stops generating keys, then waits for the background goroutines to finish.
+// The keys already in the pool are still drawn.
+func (kp *KeyPool) Close() error {
+	kp.cancel()
+	kp.wg.Wait()
+	return nil
+}
+
+var defaultKeyPool struct {
+	sync.RWMutex
+	pool *KeyPool
+}
+
+// SetDefaultKeyPool sets the pool that Generate draws the keys from without UseKeyPool.
+// nil disables the default pool.
+func SetDefaultKeyPool(pool *KeyPool) {
+	defaultKeyPool.Lock()
+	defer defaultKeyPool.Unlock()
+	defaultKeyPool.pool = pool
+}
+
+// UseKeyPool returns an option of drawing the private key from pool.
+// nil disables the default pool.
+func UseKeyPool(pool *KeyPool) Option {
+	return func(p *param) {
+		p.keyPool = pool
+		p.keyPoolSet = true
+	}
+}
+
+// newSigner returns the private key drawn from the pool, or generated on demand.
+func (p *param) newSigner() (crypto.Signer, error) {
+	pool := p.keyPool
+	if !p.keyPoolSet {
+		defaultKeyPool.RLock()
+		pool = defaultKeyPool.pool
+		defaultKeyPool.RUnlock()
+	}
+	if pool != nil && !p.fixedKey {
+		if key, ok := pool.get(p.keyType); ok {
+			return key, nil
+		}
+	}
+	return p.genSigner()
+}
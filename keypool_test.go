@@ -0,0 +1,102 @@
+package cert4now_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func waitKeyPool(t *testing.T, pool *cert4now.KeyPool, generated int64) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for pool.Stats().Generated < generated {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout: %+v", pool.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestKeyPool(t *testing.T) {
+	pool, err := cert4now.NewKeyPool(context.Background(), 2, cert4now.ECDSA(elliptic.P256()), cert4now.Ed25519())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	waitKeyPool(t, pool, 4)
+
+	cert, err := cert4now.Generate(cert4now.UseKeyPool(pool), cert4now.ECDSA(elliptic.P256()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, ok := cert.PrivateKey.(*ecdsa.PrivateKey); !ok || key.Curve != elliptic.P256() {
+		t.Errorf("unexpected key %T", cert.PrivateKey)
+	}
+	cert, err = cert4now.Generate(cert4now.UseKeyPool(pool), cert4now.Ed25519())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cert.PrivateKey.(ed25519.PrivateKey); !ok {
+		t.Errorf("unexpected key %T", cert.PrivateKey)
+	}
+	if _, err := cert4now.Generate(cert4now.UseKeyPool(pool), cert4now.ECDSA(elliptic.P384())); err != nil {
+		t.Fatal(err)
+	}
+	_, key, _ := ed25519.GenerateKey(nil)
+	if _, err := cert4now.Generate(cert4now.UseKeyPool(pool), cert4now.Signer(key)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := pool.Stats(); got.Hits != 2 || got.Misses != 1 {
+		t.Errorf("want 2 hits and 1 miss, got %+v", got)
+	}
+
+	pool.Close()
+	// Each of the types has at most size keys in the pool and a key waiting, besides the keys drawn.
+	generated := pool.Stats().Generated
+	if got := generated; got > 8 {
+		t.Errorf("want at most 8 keys generated, got %d", got)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if got := pool.Stats().Generated; got != generated {
+		t.Errorf("generated after Close: %d", got)
+	}
+}
+
+func TestKeyPool_default(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pool, err := cert4now.NewKeyPool(ctx, 1, cert4now.Ed25519())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert4now.SetDefaultKeyPool(pool)
+	defer cert4now.SetDefaultKeyPool(nil)
+	waitKeyPool(t, pool, 1)
+
+	if _, err := cert4now.Generate(cert4now.Ed25519()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cert4now.Generate(cert4now.Ed25519(), cert4now.UseKeyPool(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if got := pool.Stats(); got.Hits != 1 || got.Misses != 0 {
+		t.Errorf("want 1 hit, got %+v", got)
+	}
+
+	cancel()
+	pool.Close()
+}
+
+func TestKeyPool_unsupported(t *testing.T) {
+	_, err := cert4now.NewKeyPool(context.Background(), 1, cert4now.RSA(512))
+	var keyErr *cert4now.UnsupportedKeyError
+	if !errors.As(err, &keyErr) {
+		t.Errorf("want UnsupportedKeyError, got %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package cert4now
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+)
+
+// LintRule identifies a rule of Lint.
+type LintRule string
+
+// The rules of Lint.
+const (
+	LintLeafIsCA            LintRule = "leaf-is-ca"
+	LintCAWithoutCertSign   LintRule = "ca-without-cert-sign"
+	LintRootWithExtKeyUsage LintRule = "root-with-ext-key-usage"
+	LintServerWithoutSANs   LintRule = "server-without-sans"
+	LintCommonNameNotInSANs LintRule = "common-name-not-in-sans"
+	LintExceedsAuthority    LintRule = "exceeds-authority"
+	LintLifetimeTooLong     LintRule = "lifetime-too-long"
+	LintWeakRSAKey          LintRule = "weak-rsa-key"
+	LintIPAddressInDNSNames LintRule = "ip-address-in-dns-names"
+)
+
+const (
+	maxServerLifetime = 398 * 24 * time.Hour
+	minRSABits        = 2048
+)
+
+// LintFinding is a problem found by Lint.
+type LintFinding struct {
+	Rule    LintRule
+	Message string
+}
+
+func (f LintFinding) String() string {
+	return string(f.Rule) + ": " + f.Message
+}
+
+// LintError is the error Generate returns in the strict mode of linting.
+type LintError struct {
+	Findings []LintFinding
+}
+
+func (e *LintError) Error() string {
+	msgs := make([]string, len(e.Findings))
+	for i, f := range e.Findings {
+		msgs[i] = f.String()
+	}
+	return "lint: " + strings.Join(msgs, "; ")
+}
+
+type lintMode int
+
+const (
+	lintWarn lintMode = iota
+	lintOff
+	lintStrict
+)
+
+// NoLint returns an option of not linting the certificate.
+func NoLint() Option {
+	return func(p *param) {
+		p.lintMode = lintOff
+	}
+}
+
+// LintStrict returns an option of failing with *LintError if linting the certificate finds any problem.
+func LintStrict() Option {
+	return func(p *param) {
+		p.lintMode = lintStrict
+	}
+}
+
+// LintHandler returns an option of setting the handler of the problems found by linting the certificate.
+// The default handler writes the problems with the standard logger.
+func LintHandler(handler func(LintFinding)) Option {
+	return func(p *param) {
+		p.lintHandler = handler
+	}
+}
+
+func logLintFinding(f LintFinding) {
+	log.Printf("cert4now: lint: %s", f)
+}
+
+// Lint checks cert for the common mistakes, then returns the problems found.
+// issuer is the certificate of the authority, or nil if cert is self signed.
+// Generate lints the template with the public key before signing.
+func Lint(cert, issuer *x509.Certificate) []LintFinding {
+	var findings []LintFinding
+	add := func(rule LintRule, format string, a ...interface{}) {
+		findings = append(findings, LintFinding{Rule: rule, Message: fmt.Sprintf(format, a...)})
+	}
+
+	hasEKU := func(usage x509.ExtKeyUsage) bool {
+		for _, v := range cert.ExtKeyUsage {
+			if v == usage {
+				return true
+			}
+		}
+		return false
+	}
+	isServer := hasEKU(x509.ExtKeyUsageServerAuth) || hasEKU(x509.ExtKeyUsageAny)
+	hasSANs := len(cert.DNSNames) > 0 || len(cert.IPAddresses) > 0
+
+	if cert.IsCA {
+		// Generate gives serverAuth and clientAuth by default, so IsCA(true) alone has them.
+		// They are reported once, by LintRootWithExtKeyUsage if the CA is a root.
+		if hasSANs {
+			add(LintLeafIsCA, "the CA has the DNS names or the IP addresses of a server")
+		} else if issuer != nil && (hasEKU(x509.ExtKeyUsageServerAuth) || hasEKU(x509.ExtKeyUsageClientAuth)) {
+			add(LintLeafIsCA, "the CA has the extended key usage serverAuth or clientAuth; give ExtKeyUsage() to clear it")
+		}
+		if cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+			add(LintCAWithoutCertSign, "the CA lacks the key usage certSign")
+		}
+		if issuer == nil && len(cert.ExtKeyUsage) > 0 {
+			add(LintRootWithExtKeyUsage, "the root CA has the extended key usages; give ExtKeyUsage() to clear them")
+		}
+	}
+
+	if !cert.IsCA && isServer {
+		if !hasSANs {
+			add(LintServerWithoutSANs, "the server certificate has no DNS names nor IP addresses")
+		}
+		if lifetime := cert.NotAfter.Sub(cert.NotBefore); lifetime > maxServerLifetime {
+			add(LintLifetimeTooLong, "the lifetime %d days of the server certificate is over %d days", lifetime/(24*time.Hour), maxServerLifetime/(24*time.Hour))
+		}
+	}
+
+	if cn := cert.Subject.CommonName; cn != "" && hasSANs && !containsName(cert, cn) {
+		add(LintCommonNameNotInSANs, "the common name %q is not in the subject alternative names", cn)
+	}
+
+	// The times in the certificates are in seconds.
+	if notAfter := cert.NotAfter.Truncate(time.Second); issuer != nil && notAfter.After(issuer.NotAfter.Truncate(time.Second)) {
+		add(LintExceedsAuthority, "NotAfter %s is beyond NotAfter %s of the authority", notAfter.Format(time.RFC3339), issuer.NotAfter.Format(time.RFC3339))
+	}
+
+	if key, ok := cert.PublicKey.(*rsa.PublicKey); ok && key.N.BitLen() < minRSABits {
+		add(LintWeakRSAKey, "the RSA key of %d bits is under %d bits", key.N.BitLen(), minRSABits)
+	}
+
+	for _, name := range cert.DNSNames {
+		if net.ParseIP(name) != nil {
+			add(LintIPAddressInDNSNames, "the IP address %q is in the DNS names", name)
+		}
+	}
+
+	return findings
+}
+
+func containsName(cert *x509.Certificate, name string) bool {
+	for _, v := range cert.DNSNames {
+		if strings.EqualFold(v, name) {
+			return true
+		}
+	}
+	if ip := net.ParseIP(name); ip != nil {
+		for _, v := range cert.IPAddresses {
+			if v.Equal(ip) {
+				return true
+			}
+		}
+	}
+	for _, v := range cert.EmailAddresses {
+		if strings.EqualFold(v, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// lint lints the template signed by the authority of p according to the mode of p.
+func (p *param) lint(template *x509.Certificate) error {
+	if p.lintMode == lintOff {
+		return nil
+	}
+	var findings []LintFinding
+	for _, f := range Lint(template, p.authority) {
+		// The common name generated by default is never a name of the subject alternative names.
+		if f.Rule == LintCommonNameNotInSANs && p.defaultSubject {
+			continue
+		}
+		findings = append(findings, f)
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+	if p.lintMode == lintStrict {
+		return &LintError{Findings: findings}
+	}
+	handler := p.lintHandler
+	if handler == nil {
+		handler = logLintFinding
+	}
+	for _, f := range findings {
+		handler(f)
+	}
+	return nil
+}
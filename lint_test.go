@@ -0,0 +1,108 @@
+package cert4now_test
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func TestLint(t *testing.T) {
+	root := generateCA(t, cert4now.CommonName("Root CA"), cert4now.AddDate(5, 0, 0), cert4now.NoLint())
+
+	cases := []struct {
+		Name    string
+		Options []cert4now.Option
+		Want    []cert4now.LintRule
+	}{
+		{"server", []cert4now.Option{cert4now.CommonName("www.example.com"), cert4now.Names("www.example.com", "192.0.2.1")}, nil},
+		{"leaf is ca", []cert4now.Option{cert4now.Names("www.example.com"), cert4now.IsCA(true), cert4now.KeyUsage(x509.KeyUsageCertSign)}, []cert4now.LintRule{cert4now.LintLeafIsCA}},
+		{"ca with default ext key usage", []cert4now.Option{cert4now.IsCA(true), cert4now.KeyUsage(x509.KeyUsageCertSign)}, []cert4now.LintRule{cert4now.LintLeafIsCA}},
+		{"ca without certSign", []cert4now.Option{cert4now.IsCA(true), cert4now.ExtKeyUsage(), cert4now.KeyUsage(x509.KeyUsageDigitalSignature)}, []cert4now.LintRule{cert4now.LintCAWithoutCertSign}},
+		{"server without sans", nil, []cert4now.LintRule{cert4now.LintServerWithoutSANs}},
+		{"common name not in sans", []cert4now.Option{cert4now.CommonName("www"), cert4now.Names("www.example.com")}, []cert4now.LintRule{cert4now.LintCommonNameNotInSANs}},
+		{"exceeds authority", []cert4now.Option{cert4now.Names("www.example.com"), cert4now.AddDate(6, 0, 0), cert4now.ExtKeyUsage(x509.ExtKeyUsageClientAuth)}, []cert4now.LintRule{cert4now.LintExceedsAuthority}},
+		{"within authority in seconds", []cert4now.Option{cert4now.Names("www.example.com"), cert4now.NotAfter(parseLeaf(t, root).NotAfter.Add(500 * time.Millisecond)), cert4now.ExtKeyUsage(x509.ExtKeyUsageClientAuth)}, nil},
+		{"lifetime too long", []cert4now.Option{cert4now.Names("www.example.com"), cert4now.AddDate(0, 0, 399)}, []cert4now.LintRule{cert4now.LintLifetimeTooLong}},
+		{"weak rsa key", []cert4now.Option{cert4now.Names("www.example.com"), cert4now.RSA(1024)}, []cert4now.LintRule{cert4now.LintWeakRSAKey}},
+		{"ip address in dns names", []cert4now.Option{cert4now.DNSNames("www.example.com", "192.0.2.1")}, []cert4now.LintRule{cert4now.LintIPAddressInDNSNames}},
+	}
+	for _, c := range cases {
+		var got []cert4now.LintRule
+		handler := cert4now.LintHandler(func(f cert4now.LintFinding) {
+			got = append(got, f.Rule)
+		})
+		options := append([]cert4now.Option{cert4now.Authority(root), handler}, c.Options...)
+		if _, err := cert4now.Generate(options...); err != nil {
+			t.Errorf("%s: %v", c.Name, err)
+			continue
+		}
+		if len(got) != len(c.Want) || len(got) > 0 && got[0] != c.Want[0] {
+			t.Errorf("%s: want %v, got %v", c.Name, c.Want, got)
+		}
+	}
+
+	// The findings are written with the standard logger by default.
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	if _, err := cert4now.Generate(cert4now.IsCA(true), cert4now.KeyUsage(x509.KeyUsageDigitalSignature)); err != nil {
+		t.Fatal(err)
+	}
+	for _, rule := range []cert4now.LintRule{cert4now.LintCAWithoutCertSign, cert4now.LintRootWithExtKeyUsage} {
+		if !strings.Contains(buf.String(), string(rule)) {
+			t.Errorf("want %s logged, got %q", rule, buf.String())
+		}
+	}
+	buf.Reset()
+	if _, err := cert4now.Generate(cert4now.IsCA(true), cert4now.KeyUsage(x509.KeyUsageDigitalSignature), cert4now.NoLint()); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("want nothing logged with NoLint, got %q", buf.String())
+	}
+
+	// The default extended key usages of the root CA are reported once.
+	_, err := cert4now.Generate(cert4now.IsCA(true), cert4now.KeyUsage(x509.KeyUsageDigitalSignature), cert4now.LintStrict())
+	var lerr *cert4now.LintError
+	if !errors.As(err, &lerr) {
+		t.Fatalf("want LintError, got %v", err)
+	}
+	if want := 2; len(lerr.Findings) != want {
+		t.Errorf("want %d findings, got %v", want, lerr.Findings)
+	}
+
+	_, err = cert4now.Generate(cert4now.NoLint(), cert4now.LintStrict(), cert4now.NoLint())
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLint_certificate(t *testing.T) {
+	root := generateCA(t, cert4now.CommonName("Root CA"), cert4now.ExtKeyUsage(x509.ExtKeyUsageCodeSigning), cert4now.NoLint())
+	findings := cert4now.Lint(parseLeaf(t, root), nil)
+	if len(findings) != 1 || findings[0].Rule != cert4now.LintRootWithExtKeyUsage {
+		t.Errorf("unexpected findings %v", findings)
+	}
+
+	leaf, err := cert4now.Generate(
+		cert4now.Authority(root),
+		cert4now.CommonName("www.example.com"),
+		cert4now.Names("www.example.com"),
+		cert4now.NotBefore(time.Now()),
+		cert4now.AddDate(0, 3, 0),
+		cert4now.LintStrict(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if findings := cert4now.Lint(parseLeaf(t, leaf), parseLeaf(t, root)); len(findings) != 0 {
+		t.Errorf("unexpected findings %v", findings)
+	}
+}
@@ -0,0 +1,67 @@
+package cert4now
+
+import (
+	"context"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// fqdnLookupTimeout bounds looking up the FQDN, so that an unreachable DNS server never blocks LocalNames.
+const fqdnLookupTimeout = time.Second
+
+// LocalNames returns an option of appending the names of the local host like Names,
+// that are the host name, the FQDN, localhost, the loopback addresses and the addresses of the interfaces up.
+// The link-local addresses are excluded since they are useless without the zone.
+// The FQDN is looked up with DNS for a second at most, and omitted if not found in time.
+func LocalNames() Option {
+	return func(p *param) {
+		var names []string
+		names, p.err = localNames()
+		Names(names...)(p)
+	}
+}
+
+func localNames() ([]string, error) {
+	names := []string{"localhost"}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	names = append(names, hostname)
+	if !strings.Contains(hostname, ".") {
+		// The FQDN is of the best effort, the host name is enough without DNS.
+		ctx, cancel := context.WithTimeout(context.Background(), fqdnLookupTimeout)
+		cname, err := net.DefaultResolver.LookupCNAME(ctx, hostname)
+		cancel()
+		if err == nil {
+			if fqdn := strings.TrimSuffix(cname, "."); strings.Contains(fqdn, ".") {
+				names = append(names, fqdn)
+			}
+		}
+	}
+	names = append(names, "127.0.0.1", "::1")
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok || ipnet.IP.IsLinkLocalUnicast() || ipnet.IP.IsMulticast() {
+				continue
+			}
+			names = append(names, ipnet.IP.String())
+		}
+	}
+	return names, nil
+}
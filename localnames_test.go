@@ -0,0 +1,57 @@
+package cert4now_test
+
+import (
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func TestLocalNames(t *testing.T) {
+	cert, err := cert4now.Generate(cert4now.Ed25519(), cert4now.LocalNames())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"localhost", strings.ToLower(hostname)} {
+		if err := parseLeaf(t, cert).VerifyHostname(name); err != nil {
+			t.Error(err)
+		}
+	}
+	for _, ip := range []string{"127.0.0.1", "::1"} {
+		if err := parseLeaf(t, cert).VerifyHostname(ip); err != nil {
+			t.Error(err)
+		}
+	}
+	for _, ip := range parseLeaf(t, cert).IPAddresses {
+		if ip.IsLinkLocalUnicast() {
+			t.Errorf("link-local address %v", ip)
+		}
+	}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.IsGlobalUnicast() {
+				if err := parseLeaf(t, cert).VerifyHostname(ipnet.IP.String()); err != nil {
+					t.Error(err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package cert4now
+
+import (
+	"net"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// idnaProfile converts the Unicode host names into A-labels of punycode, lower-casing them.
+// The underscore and the wildcard are left for validateDNSName.
+var idnaProfile = idna.New(idna.MapForLookup(), idna.StrictDomainName(false))
+
+// WildcardBareDomain returns an option of appending the bare domain of each wildcard of the DNSNames,
+// such as example.com for *.example.com, since the wildcard does not match it.
+func WildcardBareDomain() Option {
+	return func(p *param) {
+		p.wildcardBareDomain = true
+	}
+}
+
+// normalizeNames converts the DNSNames into the lower-cased A-labels without the trailing dot,
+// and the IPv4-mapped IPv6 addresses into IPv4, then removes the duplicates.
+// The names failed to convert are removed and reported in the errors.
+func (p *param) normalizeNames() Errors {
+	var errs Errors
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, name := range p.dnsNames {
+		ascii, err := normalizeDNSName(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		add(ascii)
+		if p.wildcardBareDomain && strings.HasPrefix(ascii, "*.") {
+			add(ascii[2:])
+		}
+	}
+	p.dnsNames = names
+
+	var ips []net.IP
+	seenIP := make(map[string]bool)
+	for _, ip := range p.ipAddresses {
+		if v4 := ip.To4(); v4 != nil {
+			ip = v4
+		}
+		if !seenIP[string(ip)] {
+			seenIP[string(ip)] = true
+			ips = append(ips, ip)
+		}
+	}
+	p.ipAddresses = ips
+	return errs
+}
+
+// normalizeDNSName returns name in the lower-cased A-labels without the trailing dot.
+func normalizeDNSName(name string) (string, error) {
+	if _, _, err := net.ParseCIDR(name); err == nil {
+		return "", &InvalidNameError{Name: name, Reason: "CIDR is not a name"}
+	}
+	if ip := net.ParseIP(strings.Trim(name, "[]")); ip != nil {
+		// Left as is to be found by the lint of ip-address-in-dns-names.
+		return name, nil
+	}
+	ascii, err := idnaProfile.ToASCII(strings.TrimSuffix(name, "."))
+	if err != nil {
+		return "", &InvalidNameError{Name: name, Reason: strings.TrimPrefix(err.Error(), "idna: ")}
+	}
+	return ascii, nil
+}
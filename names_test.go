@@ -0,0 +1,55 @@
+package cert4now_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takumakei/go-cert4now"
+)
+
+func TestNames_normalize(t *testing.T) {
+	cert, err := cert4now.Generate(
+		cert4now.Ed25519(),
+		cert4now.Names("Bücher.example", "WWW.Example.com.", "www.example.com", "*.Example.com", "::ffff:192.0.2.1", "[2001:db8::1]"),
+		cert4now.DNSNames("xn--bcher-kva.example", "_srv.example.com"),
+		cert4now.IPAddresses(net.ParseIP("192.0.2.1").To4()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDNS := []string{"xn--bcher-kva.example", "www.example.com", "*.example.com", "_srv.example.com"}
+	if diff := cmp.Diff(wantDNS, parseLeaf(t, cert).DNSNames); diff != "" {
+		t.Errorf("DNSNames (-want +got):\n%s", diff)
+	}
+	wantIP := []net.IP{net.ParseIP("192.0.2.1").To4(), net.ParseIP("2001:db8::1")}
+	if diff := cmp.Diff(wantIP, parseLeaf(t, cert).IPAddresses); diff != "" {
+		t.Errorf("IPAddresses (-want +got):\n%s", diff)
+	}
+}
+
+func TestWildcardBareDomain(t *testing.T) {
+	cert, err := cert4now.Generate(
+		cert4now.Ed25519(),
+		cert4now.WildcardBareDomain(),
+		cert4now.Names("*.example.com", "*.Bücher.example", "xn--bcher-kva.example"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"*.example.com", "example.com", "*.xn--bcher-kva.example", "xn--bcher-kva.example"}
+	if diff := cmp.Diff(want, parseLeaf(t, cert).DNSNames); diff != "" {
+		t.Errorf("DNSNames (-want +got):\n%s", diff)
+	}
+}
+
+func TestNames_invalid(t *testing.T) {
+	for _, name := range []string{"10.0.0.0/8", "-a.example", "xn--zz.example", "a..example", "*.com", "a.*.example", "a b.example"} {
+		_, err := cert4now.Generate(cert4now.Ed25519(), cert4now.Names(name))
+		var e *cert4now.InvalidNameError
+		if !errors.As(err, &e) {
+			t.Errorf("%q: want InvalidNameError, got %v", name, err)
+		}
+	}
+}
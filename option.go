@@ -3,6 +3,7 @@ package cert4now
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
@@ -12,6 +13,7 @@ import (
 	"errors"
 	"math/big"
 	"net"
+	"strings"
 	"time"
 )
 
@@ -22,6 +24,7 @@ type Option func(*param)
 func Subject(name pkix.Name) Option {
 	return func(p *param) {
 		p.subject = &name
+		p.rawSubject = nil
 	}
 }
 
@@ -32,6 +35,7 @@ func CommonName(name string) Option {
 			p.subject = &pkix.Name{}
 		}
 		p.subject.CommonName = name
+		p.rawSubject = nil
 	}
 }
 
@@ -69,6 +73,12 @@ func AddDate(years, months, days int) Option {
 // Signer returns an option of setting the private key.
 func Signer(signer crypto.Signer) Option {
 	return func(p *param) {
+		p.fixedKey = true
+		if signer == nil {
+			p.keyType = keyType{}
+		} else {
+			p.keyType = keyTypeOf(signer.Public())
+		}
 		p.genSigner = func() (crypto.Signer, error) {
 			return signer, nil
 		}
@@ -78,6 +88,8 @@ func Signer(signer crypto.Signer) Option {
 // RSA returns an option of generating then setting the private key.
 func RSA(bits int) Option {
 	return func(p *param) {
+		p.keyType = keyType{algorithm: x509.RSA, bits: bits}
+		p.fixedKey = false
 		p.genSigner = func() (crypto.Signer, error) {
 			return rsa.GenerateKey(rand.Reader, bits)
 		}
@@ -87,16 +99,53 @@ func RSA(bits int) Option {
 // ECDSA returns an option of generating then setting the private key.
 func ECDSA(c elliptic.Curve) Option {
 	return func(p *param) {
+		p.keyType = keyType{algorithm: x509.ECDSA, curve: c}
+		p.fixedKey = false
 		p.genSigner = func() (crypto.Signer, error) {
 			return ecdsa.GenerateKey(c, rand.Reader)
 		}
 	}
 }
 
+// Ed25519 returns an option of generating then setting the private key.
+func Ed25519() Option {
+	return func(p *param) {
+		p.keyType = keyType{algorithm: x509.Ed25519}
+		p.fixedKey = false
+		p.genSigner = func() (crypto.Signer, error) {
+			_, key, err := ed25519.GenerateKey(rand.Reader)
+			return key, err
+		}
+	}
+}
+
+// CertificateRequest returns an option of issuing a certificate for the public key of csr.
+// The subject and the subject alternative names of csr are used unless other options tell otherwise.
+// The certificate generated with this option has no private key.
+func CertificateRequest(csr *x509.CertificateRequest) Option {
+	return func(p *param) {
+		if p.err = csr.CheckSignature(); p.err != nil {
+			return
+		}
+		p.publicKey = csr.PublicKey
+		p.keyType = keyTypeOf(csr.PublicKey)
+		if p.subject == nil {
+			subject := csr.Subject
+			p.subject = &subject
+		}
+		p.dnsNames = append(p.dnsNames, csr.DNSNames...)
+		p.emailAddresses = append(p.emailAddresses, csr.EmailAddresses...)
+		p.ipAddresses = append(p.ipAddresses, csr.IPAddresses...)
+	}
+}
+
 // KeyUsage returns an option of setting the KeyUsage.
+// Without this option, the KeyUsage is digitalSignature, certSign and crlSign for CA,
+// otherwise digitalSignature, and keyEncipherment only for RSA keys.
 func KeyUsage(usage x509.KeyUsage) Option {
 	return func(p *param) {
 		p.keyUsage = usage
+		p.keyUsageSet = true
 	}
 }
 
@@ -116,6 +165,7 @@ func DNSNamesReset(names ...string) Option {
 }
 
 // DNSNames returns an option of appending the DNSNames.
+// The Unicode names are converted into punycode, and the names are lower-cased and de-duplicated.
 func DNSNames(names ...string) Option {
 	names = filterNonEmptyString(names)
 	return func(p *param) {
@@ -140,7 +190,8 @@ func EmailAddresses(emails ...string) Option {
 }
 
 // Names returns an option of appending DNSNames and IPAddresses.
-// For each of names, the name that success to net.ParseIP is appended to IPAddresses.
+// For each of names, the name that success to net.ParseIP is appended to IPAddresses,
+// the brackets around an IPv6 address are allowed.
 // The name that failed to net.ParseIP is appended to DNSNames.
 func Names(names ...string) Option {
 	return func(p *param) {
@@ -148,7 +199,7 @@ func Names(names ...string) Option {
 		var dns []string
 		for _, v := range names {
 			if len(v) > 0 {
-				if ip := net.ParseIP(v); ip != nil {
+				if ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(v, "["), "]")); ip != nil {
 					ips = append(ips, ip)
 				} else {
 					dns = append(dns, v)
@@ -197,6 +248,10 @@ var ErrInvalidAuthorityKey = errors.New("authority's PrivateKey is not type of c
 // Authority returns an option of setting the authority.
 func Authority(cert tls.Certificate) Option {
 	return func(p *param) {
+		if len(cert.Certificate) == 0 {
+			p.err = ErrEmptyAuthority
+			return
+		}
 		var ok bool
 		p.authority, p.err = x509.ParseCertificate(cert.Certificate[0])
 		if p.err != nil {
@@ -5,10 +5,11 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/hex"
-	"math"
 	"math/big"
 	"net"
+	"net/url"
 	"time"
 )
 
@@ -18,51 +19,106 @@ type param struct {
 	chain        [][]byte
 
 	subject               *pkix.Name
+	rawSubject            []byte
 	serialNumber          *big.Int
+	serialPolicy          SerialPolicy
+	serialRegistry        SerialRegistry
 	genSigner             func() (crypto.Signer, error)
+	publicKey             crypto.PublicKey
+	keyType               keyType
+	fixedKey              bool
+	keyPool               *KeyPool
+	keyPoolSet            bool
 	notBefore             time.Time
 	notAfter              time.Time
 	keyUsage              x509.KeyUsage
+	keyUsageSet           bool
 	extKeyUsage           []x509.ExtKeyUsage
+	unknownExtKeyUsage    []asn1.ObjectIdentifier
 	basicConstraintsValid bool
 	isCA                  bool
+	maxPathLen            int
+	maxPathLenZero        bool
+	lifetime              time.Duration
+	signatureAlgorithm    x509.SignatureAlgorithm
+	signatureHash         crypto.Hash
+	rsaPSS                bool
 
 	dnsNames       []string
 	emailAddresses []string
 	ipAddresses    []net.IP
+	uris           []*url.URL
+
+	wildcardBareDomain bool
+
+	subjectKeyID    []byte
+	extraExtensions []pkix.Extension
+
+	store    Store
+	metadata map[string]string
+
+	cache         *Cache
+	cacheKey      string
+	cacheRelative bool
+
+	lintMode       lintMode
+	lintHandler    func(LintFinding)
+	defaultSubject bool
 
 	err error
 }
 
+// newParam returns the parameters of the defaults of Generate.
+func newParam() *param {
+	return &param{
+		extKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+}
+
+// apply applies the options, then validates the parameters.
+// It returns all the errors found in Errors if more than one.
 func (p *param) apply(options ...Option) error {
+	var errs Errors
 	for _, option := range options {
 		option(p)
-		if err := p.err; err != nil {
-			return err
+		if p.err != nil {
+			errs = append(errs, p.err)
+			p.err = nil
 		}
 	}
-	return p.fill()
-}
-
-func (p *param) fill() (err error) {
-	if p.serialNumber == nil {
-		p.serialNumber, err = rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
+	errs = append(errs, p.normalizeNames()...)
+	if p.cache != nil && p.store == nil && p.serialRegistry == nil && p.publicKey == nil {
+		var err error
+		p.cacheKey, p.cacheRelative, err = p.cacheKeyOf(time.Now())
 		if err != nil {
-			return
+			errs = append(errs, err)
 		}
 	}
+	if err := p.fill(); err != nil {
+		return append(errs, err).err()
+	}
+	return append(errs, p.validate()...).err()
+}
 
+func (p *param) fill() (err error) {
 	if p.subject == nil {
-		sn := p.serialNumber.Bytes()
-		if len(sn) > 3 {
-			sn = sn[:3]
+		// The serial number is assigned after the key is generated, then the random bytes stand for it.
+		sn := make([]byte, 3)
+		if p.serialNumber != nil {
+			sn = p.serialNumber.Bytes()
+			if len(sn) > 3 {
+				sn = sn[:3]
+			}
+		} else if _, err = rand.Read(sn); err != nil {
+			return
 		}
 		p.subject = &pkix.Name{
 			CommonName: "Self Signed Cert " + hex.EncodeToString(sn),
 		}
+		p.defaultSubject = true
 	}
 
-	if p.genSigner == nil {
+	if p.genSigner == nil && p.publicKey == nil {
 		RSA(2048)(p)
 	}
 
@@ -71,8 +127,33 @@ func (p *param) fill() (err error) {
 	}
 
 	if p.notAfter.IsZero() {
-		p.notAfter = p.notBefore.AddDate(0, 0, 90)
+		if p.lifetime > 0 {
+			p.notAfter = p.notBefore.Add(p.lifetime)
+		} else {
+			p.notAfter = p.notBefore.AddDate(0, 0, 90)
+		}
 	}
 
 	return
 }
+
+// keyUsageFor returns the key usage of the certificate of pub.
+// Unless given by KeyUsage, it is computed from the role of the certificate and the algorithm of pub,
+// since only RSA keys can encipher keys or data.
+func (p *param) keyUsageFor(pub crypto.PublicKey) x509.KeyUsage {
+	if p.keyUsageSet {
+		return p.keyUsage
+	}
+	usage := p.keyUsage
+	if usage == 0 {
+		if p.isCA {
+			usage = x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+		} else {
+			usage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+		}
+	}
+	if keyTypeOf(pub).algorithm != x509.RSA {
+		usage &^= x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment
+	}
+	return usage
+}
@@ -0,0 +1,686 @@
+package cert4now
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PKISpec describes a whole PKI, that is roots, intermediates and leaves.
+// It is written in YAML or JSON, for example:
+//
+//	certificates:
+//	  - name: root
+//	    ca: true
+//	    subject: {commonName: "Dev Root CA"}
+//	    key: ecdsa-p384
+//	    lifetime: 20y
+//	    output: {cert: root.crt, key: root.key}
+//	  - name: web
+//	    issuer: root
+//	    names: [localhost, 127.0.0.1]
+//	    lifetime: 90d
+//	    output: {cert: web.crt, key: web.key, chain: true}
+type PKISpec struct {
+	// Dir is the base directory of relative output paths.
+	// LoadPKISpec sets the directory of the spec file if empty.
+	Dir string `yaml:"dir"`
+
+	Certificates []CertificateSpec `yaml:"certificates"`
+}
+
+// CertificateSpec describes a certificate in PKISpec.
+type CertificateSpec struct {
+	// Name identifies the certificate in the spec.
+	Name string `yaml:"name"`
+
+	// Issuer is the name of the certificate signing this certificate.
+	// The certificate is self signed if empty.
+	Issuer string `yaml:"issuer"`
+
+	// CA makes the certificate a certificate authority.
+	CA bool `yaml:"ca"`
+
+	Subject SubjectSpec `yaml:"subject"`
+
+	// Names are DNS names and IP addresses.
+	Names  []string `yaml:"names"`
+	Emails []string `yaml:"emails"`
+
+	// Key is the type of the key: rsa, rsa-3072, rsa-4096, ecdsa, ecdsa-p384, ecdsa-p521 or ed25519.
+	// The default is rsa, that is RSA 2048 bits.
+	Key string `yaml:"key"`
+
+	// Lifetime is the validity period such as 20y, 6m, 90d, 2w or 36h.
+	// The default is 10y for CA, 90d otherwise.
+	Lifetime string `yaml:"lifetime"`
+
+	// RenewBefore is the period before NotAfter to regenerate the certificate.
+	// The default is a third of Lifetime.
+	RenewBefore string `yaml:"renewBefore"`
+
+	// KeyUsage and ExtKeyUsage are the names accepted by ParseKeyUsage and ParseExtKeyUsage.
+	KeyUsage    []string `yaml:"keyUsage"`
+	ExtKeyUsage []string `yaml:"extKeyUsage"`
+
+	Output OutputSpec `yaml:"output"`
+}
+
+// SubjectSpec describes the subject of a certificate.
+type SubjectSpec struct {
+	CommonName         string   `yaml:"commonName"`
+	Organization       []string `yaml:"organization"`
+	OrganizationalUnit []string `yaml:"organizationalUnit"`
+	Country            []string `yaml:"country"`
+	Province           []string `yaml:"province"`
+	Locality           []string `yaml:"locality"`
+}
+
+// OutputSpec describes the files of a certificate.
+// The certificate is reused across Materialize only if both Cert and Key are given.
+type OutputSpec struct {
+	Cert string `yaml:"cert"`
+	Key  string `yaml:"key"`
+
+	// Chain writes the chain following the certificate. It takes no effect in DER.
+	Chain bool `yaml:"chain"`
+
+	// Format is pem or der. The default is pem.
+	Format string `yaml:"format"`
+
+	// KeyFormat is pkcs8, pkcs1 or sec1. The default is pkcs8.
+	KeyFormat string `yaml:"keyFormat"`
+}
+
+// PKI is the result of materializing PKISpec.
+type PKI struct {
+	// Certificates are the certificates by the name.
+	Certificates map[string]tls.Certificate
+
+	// Generated are the names of the certificates generated by Materialize,
+	// the others are reused from the files.
+	Generated []string
+}
+
+// LoadPKISpec reads the spec in YAML or JSON from the file of filename.
+func LoadPKISpec(filename string) (*PKISpec, error) {
+	p, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := ParsePKISpec(p)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if !filepath.IsAbs(spec.Dir) {
+		spec.Dir = filepath.Join(filepath.Dir(filename), spec.Dir)
+	}
+	return spec, nil
+}
+
+// ParsePKISpec parses the spec in YAML or JSON.
+func ParsePKISpec(p []byte) (*PKISpec, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(p))
+	dec.KnownFields(true)
+	spec := &PKISpec{}
+	if err := dec.Decode(spec); err != nil {
+		return nil, err
+	}
+	if err := spec.check(); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+func (s *PKISpec) check() error {
+	specs := make(map[string]*CertificateSpec)
+	for i := range s.Certificates {
+		c := &s.Certificates[i]
+		if c.Name == "" {
+			return fmt.Errorf("certificates[%d] has no name", i)
+		}
+		if specs[c.Name] != nil {
+			return fmt.Errorf("duplicate certificate %q", c.Name)
+		}
+		specs[c.Name] = c
+	}
+	for _, c := range s.Certificates {
+		seen := map[string]bool{c.Name: true}
+		for issuer := c.Issuer; issuer != ""; issuer = specs[issuer].Issuer {
+			if specs[issuer] == nil {
+				return fmt.Errorf("issuer %q of %q is not found", issuer, c.Name)
+			}
+			if !specs[issuer].CA {
+				return fmt.Errorf("issuer %q of %q is not a CA", issuer, c.Name)
+			}
+			if seen[issuer] {
+				return fmt.Errorf("issuer of %q is circular", c.Name)
+			}
+			seen[issuer] = true
+		}
+	}
+	return nil
+}
+
+// Materialize makes the files of the certificates converge to the spec.
+// The existing certificates are reused if still valid and matching the spec,
+// otherwise they are regenerated along with all the certificates they issued.
+func (s *PKISpec) Materialize() (*PKI, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+
+	m := &materializer{
+		spec:      s,
+		now:       time.Now(),
+		pki:       &PKI{Certificates: make(map[string]tls.Certificate)},
+		generated: make(map[string]bool),
+	}
+	for i := range s.Certificates {
+		if err := m.materialize(&s.Certificates[i]); err != nil {
+			return nil, err
+		}
+	}
+	return m.pki, nil
+}
+
+type materializer struct {
+	spec      *PKISpec
+	now       time.Time
+	pki       *PKI
+	generated map[string]bool
+}
+
+func (m *materializer) materialize(c *CertificateSpec) error {
+	if _, ok := m.pki.Certificates[c.Name]; ok {
+		return nil
+	}
+
+	var issuer *tls.Certificate
+	issuerGenerated := false
+	if c.Issuer != "" {
+		for i := range m.spec.Certificates {
+			if m.spec.Certificates[i].Name == c.Issuer {
+				if err := m.materialize(&m.spec.Certificates[i]); err != nil {
+					return err
+				}
+			}
+		}
+		v := m.pki.Certificates[c.Issuer]
+		issuer = &v
+		issuerGenerated = m.generated[c.Issuer]
+	}
+
+	if !issuerGenerated {
+		if cert, ok := m.reuse(c, issuer); ok {
+			m.pki.Certificates[c.Name] = cert
+			return nil
+		}
+	}
+
+	cert, err := m.generate(c, issuer)
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.Name, err)
+	}
+	m.pki.Certificates[c.Name] = cert
+	m.pki.Generated = append(m.pki.Generated, c.Name)
+	m.generated[c.Name] = true
+	return nil
+}
+
+func (m *materializer) path(name string) string {
+	if name == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(m.spec.Dir, name)
+}
+
+func (m *materializer) generate(c *CertificateSpec, issuer *tls.Certificate) (tls.Certificate, error) {
+	options, err := c.options(m.now)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if issuer != nil {
+		options = append(options, Authority(*issuer))
+	}
+	cert, err := Generate(options...)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := m.write(c, cert); err != nil {
+		return tls.Certificate{}, err
+	}
+	return cert, nil
+}
+
+func (m *materializer) write(c *CertificateSpec, cert tls.Certificate) error {
+	der := strings.EqualFold(c.Output.Format, "der")
+
+	if name := m.path(c.Output.Cert); name != "" {
+		var buf bytes.Buffer
+		switch {
+		case der:
+			buf.Write(cert.Certificate[0])
+		case c.Output.Chain:
+			if err := WriteCertificateChain(&buf, cert); err != nil {
+				return err
+			}
+		default:
+			if err := WriteCertificate(&buf, cert); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+
+	if name := m.path(c.Output.Key); name != "" {
+		options, err := c.Output.keyOptions()
+		if err != nil {
+			return err
+		}
+		if der {
+			options = append(options, DER())
+		}
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		}
+		if err := WritePrivateKeyFile(name, cert, 0600, options...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reuse loads the certificate from the files, then returns it if it is still valid and matching the spec.
+func (m *materializer) reuse(c *CertificateSpec, issuer *tls.Certificate) (cert tls.Certificate, ok bool) {
+	certFile, keyFile := m.path(c.Output.Cert), m.path(c.Output.Key)
+	if certFile == "" || keyFile == "" {
+		return
+	}
+	leaf, key, err := loadFiles(certFile, keyFile)
+	if err != nil {
+		return
+	}
+	pub, equal := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !equal || !pub.Equal(leaf.PublicKey) {
+		return
+	}
+
+	if issuer != nil {
+		parent, err := x509.ParseCertificate(issuer.Certificate[0])
+		if err != nil || leaf.CheckSignatureFrom(parent) != nil {
+			return
+		}
+	} else if leaf.CheckSignature(leaf.SignatureAlgorithm, leaf.RawTBSCertificate, leaf.Signature) != nil {
+		// CheckSignatureFrom rejects the self signed certificate not of CA.
+		return
+	}
+
+	if !c.matches(leaf, m.now) {
+		return
+	}
+
+	cert.Certificate = [][]byte{leaf.Raw}
+	if issuer != nil {
+		cert.Certificate = append(cert.Certificate, issuer.Certificate...)
+	}
+	cert.PrivateKey = key
+	cert.Leaf = leaf
+	return cert, true
+}
+
+func loadFiles(certFile, keyFile string) (*x509.Certificate, crypto.Signer, error) {
+	p, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if block, _ := pem.Decode(p); block != nil {
+		p = block.Bytes
+	}
+	leaf, err := x509.ParseCertificate(p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p, err = os.ReadFile(keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := decodePrivateKey(p, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return leaf, key, nil
+}
+
+// parsePrivateKey parses the private key in PKCS#8, PKCS#1 or SEC 1.
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("failed to parse private key")
+}
+
+// matches reports whether the certificate is valid at now considering RenewBefore, and it matches the spec.
+func (c *CertificateSpec) matches(leaf *x509.Certificate, now time.Time) bool {
+	lifetime, err := c.lifetime()
+	if err != nil {
+		return false
+	}
+	renewBefore, err := c.renewBefore(now, lifetime)
+	if err != nil {
+		return false
+	}
+	if now.Before(leaf.NotBefore) || !now.Add(renewBefore).Before(leaf.NotAfter) {
+		return false
+	}
+
+	// The parameters are the ones of generating the certificate at NotBefore of leaf,
+	// so that the names are normalized and NotAfter is computed as Generate does.
+	// NotBefore is in the local time as Materialize gave, since AddDate differs across the daylight saving time.
+	options, err := c.options(leaf.NotBefore.In(time.Local))
+	if err != nil {
+		return false
+	}
+	p := newParam()
+	if p.apply(options...) != nil {
+		return false
+	}
+
+	subject, err := asn1.Marshal(p.subject.ToRDNSequence())
+	if err != nil || !bytes.Equal(subject, leaf.RawSubject) {
+		return false
+	}
+	if !p.notAfter.Truncate(time.Second).Equal(leaf.NotAfter) {
+		return false
+	}
+	if leaf.IsCA != p.isCA || leaf.KeyUsage != p.keyUsageFor(leaf.PublicKey) || !sameExtKeyUsage(p.extKeyUsage, leaf.ExtKeyUsage) {
+		return false
+	}
+
+	var ips, got []string
+	for _, ip := range p.ipAddresses {
+		ips = append(ips, ip.String())
+	}
+	for _, ip := range leaf.IPAddresses {
+		got = append(got, ip.String())
+	}
+	if !sameStrings(p.dnsNames, leaf.DNSNames) || !sameStrings(ips, got) || !sameStrings(p.emailAddresses, leaf.EmailAddresses) {
+		return false
+	}
+
+	return c.keyMatches(leaf.PublicKey)
+}
+
+func sameExtKeyUsage(a, b []x509.ExtKeyUsage) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, v := range a {
+		found := false
+		for _, w := range b {
+			found = found || v == w
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *CertificateSpec) options(now time.Time) ([]Option, error) {
+	options := []Option{
+		Subject(c.Subject.name()),
+		Names(c.Names...),
+		EmailAddresses(c.Emails...),
+		NotBefore(now),
+	}
+
+	key, err := c.keyOption()
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, key)
+
+	lifetime, err := c.lifetime()
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, lifetime.option(now))
+
+	if c.CA {
+		options = append(options,
+			KeyUsage(x509.KeyUsageDigitalSignature|x509.KeyUsageCertSign|x509.KeyUsageCRLSign),
+			ExtKeyUsage(),
+			IsCA(true),
+		)
+	}
+	if c.KeyUsage != nil {
+		usage, err := ParseKeyUsage(c.KeyUsage...)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, KeyUsage(usage))
+	}
+	if c.ExtKeyUsage != nil {
+		usage, err := ParseExtKeyUsage(c.ExtKeyUsage...)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, ExtKeyUsage(usage...))
+	}
+	return options, nil
+}
+
+func (s SubjectSpec) name() pkix.Name {
+	return pkix.Name{
+		CommonName:         s.CommonName,
+		Organization:       s.Organization,
+		OrganizationalUnit: s.OrganizationalUnit,
+		Country:            s.Country,
+		Province:           s.Province,
+		Locality:           s.Locality,
+	}
+}
+
+func (c *CertificateSpec) keyType() (alg string, size int, err error) {
+	alg = strings.ToLower(c.Key)
+	if alg == "" {
+		alg = "rsa"
+	}
+	if i := strings.IndexByte(alg, '-'); i >= 0 {
+		size, err = strconv.Atoi(strings.TrimPrefix(alg[i+1:], "p"))
+		if err != nil {
+			return "", 0, fmt.Errorf("unknown key %q", c.Key)
+		}
+		alg = alg[:i]
+	}
+	switch alg {
+	case "rsa":
+		if size == 0 {
+			size = 2048
+		}
+	case "ecdsa", "ec":
+		alg = "ecdsa"
+		if size == 0 {
+			size = 256
+		}
+		if size != 256 && size != 384 && size != 521 {
+			return "", 0, fmt.Errorf("unknown key %q", c.Key)
+		}
+	case "ed25519":
+		if size != 0 {
+			return "", 0, fmt.Errorf("unknown key %q", c.Key)
+		}
+	default:
+		return "", 0, fmt.Errorf("unknown key %q", c.Key)
+	}
+	return alg, size, nil
+}
+
+func (c *CertificateSpec) keyOption() (Option, error) {
+	alg, size, err := c.keyType()
+	if err != nil {
+		return nil, err
+	}
+	switch alg {
+	case "rsa":
+		return RSA(size), nil
+	case "ecdsa":
+		return ECDSA(curveOfSize(size)), nil
+	}
+	return Ed25519(), nil
+}
+
+func (c *CertificateSpec) keyMatches(pub crypto.PublicKey) bool {
+	alg, size, err := c.keyType()
+	if err != nil {
+		return false
+	}
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return alg == "rsa" && k.N.BitLen() == size
+	case *ecdsa.PublicKey:
+		return alg == "ecdsa" && k.Curve == curveOfSize(size)
+	case ed25519.PublicKey:
+		return alg == "ed25519"
+	}
+	return false
+}
+
+func curveOfSize(size int) elliptic.Curve {
+	switch size {
+	case 384:
+		return elliptic.P384()
+	case 521:
+		return elliptic.P521()
+	}
+	return elliptic.P256()
+}
+
+// period is either of a calendar period or a duration.
+type period struct {
+	years, months, days int
+	duration            time.Duration
+}
+
+func (p period) option(from time.Time) Option {
+	if p.duration > 0 {
+		return NotAfter(from.Add(p.duration))
+	}
+	return AddDate(p.years, p.months, p.days)
+}
+
+func (p period) from(t time.Time) time.Duration {
+	if p.duration > 0 {
+		return p.duration
+	}
+	return t.AddDate(p.years, p.months, p.days).Sub(t)
+}
+
+func parsePeriod(s string) (period, error) {
+	if s == "" {
+		return period{}, errors.New("empty period")
+	}
+	unit := s[len(s)-1]
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err == nil && n > 0 {
+		switch unit {
+		case 'y':
+			return period{years: n}, nil
+		case 'm':
+			return period{months: n}, nil
+		case 'w':
+			return period{days: 7 * n}, nil
+		case 'd':
+			return period{days: n}, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return period{}, fmt.Errorf("invalid period %q", s)
+	}
+	return period{duration: d}, nil
+}
+
+func (c *CertificateSpec) lifetime() (period, error) {
+	s := c.Lifetime
+	if s == "" {
+		if c.CA {
+			s = "10y"
+		} else {
+			s = "90d"
+		}
+	}
+	return parsePeriod(s)
+}
+
+func (c *CertificateSpec) renewBefore(now time.Time, lifetime period) (time.Duration, error) {
+	if c.RenewBefore == "" {
+		return lifetime.from(now) / 3, nil
+	}
+	p, err := parsePeriod(c.RenewBefore)
+	if err != nil {
+		return 0, err
+	}
+	return p.from(now), nil
+}
+
+func (o OutputSpec) keyOptions() ([]WriteOption, error) {
+	switch strings.ToLower(o.KeyFormat) {
+	case "", "pkcs8":
+		return nil, nil
+	case "pkcs1":
+		return []WriteOption{PKCS1()}, nil
+	case "sec1":
+		return []WriteOption{SEC1()}, nil
+	}
+	return nil, fmt.Errorf("unknown key format %q", o.KeyFormat)
+}
@@ -0,0 +1,194 @@
+package cert4now_test
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takumakei/go-cert4now"
+)
+
+const testPKISpec = `
+certificates:
+  - name: web
+    issuer: intermediate
+    subject: {commonName: localhost}
+    names: [localhost, 127.0.0.1]
+    key: ecdsa
+    output: {cert: web/cert.pem, key: web/key.pem, chain: true, keyFormat: sec1}
+  - name: root
+    ca: true
+    subject: {commonName: "Test Root CA", organization: [Acme]}
+    key: ecdsa-p384
+    lifetime: 20y
+    output: {cert: root.crt, key: root.key}
+  - name: intermediate
+    issuer: root
+    ca: true
+    subject: {commonName: "Test Intermediate CA"}
+    key: ed25519
+    lifetime: 5y
+    output: {cert: intermediate.der, key: intermediate.key, format: der}
+`
+
+func TestPKISpec_Materialize(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "pki.yaml")
+	if err := os.WriteFile(filename, []byte(testPKISpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := cert4now.LoadPKISpec(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pki, err := spec.Materialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"root", "intermediate", "web"}, pki.Generated); diff != "" {
+		t.Fatalf("-want +got\n%s", diff)
+	}
+
+	web, err := tls.LoadX509KeyPair(filepath.Join(dir, "web/cert.pem"), filepath.Join(dir, "web/key.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(web.Certificate) != 3 {
+		t.Fatalf("want chain of 3 certificates, got %d", len(web.Certificate))
+	}
+
+	pki, err = spec.Materialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pki.Generated) != 0 {
+		t.Fatalf("want nothing generated, got %v", pki.Generated)
+	}
+	if diff := cmp.Diff(web.Certificate, pki.Certificates["web"].Certificate); diff != "" {
+		t.Fatalf("-want +got\n%s", diff)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "intermediate.key")); err != nil {
+		t.Fatal(err)
+	}
+	pki, err = spec.Materialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"intermediate", "web"}, pki.Generated); diff != "" {
+		t.Fatalf("-want +got\n%s", diff)
+	}
+}
+
+func TestPKISpec_Materialize_changes(t *testing.T) {
+	dir := t.TempDir()
+	materialize := func(spec string) []string {
+		t.Helper()
+		s, err := cert4now.ParsePKISpec([]byte(spec))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Dir = dir
+		pki, err := s.Materialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pki.Generated
+	}
+
+	const spec = `
+certificates:
+  - name: web
+    subject: {commonName: WWW.Example.com}
+    names: [WWW.Example.com, Bücher.example, "::ffff:10.0.0.1"]
+    key: ecdsa
+    output: {cert: cert.pem, key: key.pem}
+`
+	if got := materialize(spec); len(got) != 1 {
+		t.Fatalf("want web generated, got %v", got)
+	}
+	// The names normalized by Generate match the ones of the spec.
+	if got := materialize(spec); len(got) != 0 {
+		t.Fatalf("want nothing generated, got %v", got)
+	}
+
+	for _, change := range []string{
+		"    subject: {commonName: WWW.Example.com, organization: [Acme]}\n",
+		"    keyUsage: [digitalSignature, keyAgreement]\n",
+		"    extKeyUsage: [serverAuth]\n",
+		"    lifetime: 30d\n",
+	} {
+		changed := strings.Replace(spec, "    key: ecdsa\n", "    key: ecdsa\n"+change, 1)
+		if strings.HasPrefix(change, "    subject:") {
+			changed = strings.Replace(spec, "    subject: {commonName: WWW.Example.com}\n", change, 1)
+		}
+		if got := materialize(changed); len(got) != 1 {
+			t.Errorf("%q: want web generated, got %v", change, got)
+		}
+		if got := materialize(changed); len(got) != 0 {
+			t.Errorf("%q: want nothing generated, got %v", change, got)
+		}
+	}
+}
+
+func TestPKISpec_Materialize_daylightSaving(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	local := time.Local
+	time.Local = loc
+	defer func() { time.Local = local }()
+
+	// The lifetime in days crosses the next change of the daylight saving time.
+	now := time.Now().In(loc)
+	_, offset := now.Zone()
+	days := 1
+	for ; days < 366; days++ {
+		if _, o := now.AddDate(0, 0, days).Zone(); o != offset {
+			break
+		}
+	}
+	spec, err := cert4now.ParsePKISpec([]byte(fmt.Sprintf(`
+certificates:
+  - name: web
+    names: [www.example.com]
+    key: ecdsa
+    lifetime: %dd
+    output: {cert: cert.pem, key: key.pem}
+`, days+1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Dir = t.TempDir()
+	for i, want := range []int{1, 0} {
+		pki, err := spec.Materialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pki.Generated) != want {
+			t.Fatalf("[%d] want %d generated, got %v", i, want, pki.Generated)
+		}
+	}
+}
+
+func TestParsePKISpec_error(t *testing.T) {
+	cases := []string{
+		`certificates: [{name: a, issuer: b}]`,
+		`certificates: [{name: a, issuer: b, ca: true}, {name: b, issuer: a, ca: true}]`,
+		`certificates: [{name: a}, {name: a}]`,
+		`certificates: [{name: a, issuer: b}, {name: b}]`,
+		`certificates: [{name: a, unknown: 1}]`,
+	}
+	for i, c := range cases {
+		if _, err := cert4now.ParsePKISpec([]byte(c)); err == nil {
+			t.Errorf("[%d] want error", i)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package cert4now
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Profile is the set of the defaults of a kind of certificate.
+// The options following the profile override the defaults.
+type Profile struct {
+	// KeyUsage is the key usage, except that keyEncipherment and dataEncipherment are dropped for non-RSA keys.
+	KeyUsage    x509.KeyUsage
+	ExtKeyUsage []x509.ExtKeyUsage
+
+	// IsCA makes the certificate a certificate authority.
+	IsCA bool
+
+	// MaxPathLen is the maximum number of the intermediate CAs following the CA.
+	// Negative means no limit. It takes effect only if IsCA is true.
+	MaxPathLen int
+
+	// Lifetime is the validity period from NotBefore, unless NotAfter is given by the options.
+	Lifetime time.Duration
+}
+
+const day = 24 * time.Hour
+
+// Option returns an option of applying the profile.
+func (pr Profile) Option() Option {
+	return func(p *param) {
+		p.keyUsage, p.keyUsageSet = pr.KeyUsage, false
+		p.extKeyUsage = append([]x509.ExtKeyUsage(nil), pr.ExtKeyUsage...)
+		p.basicConstraintsValid = true
+		p.isCA = pr.IsCA
+		p.maxPathLen, p.maxPathLenZero = -1, false
+		if pr.IsCA {
+			MaxPathLen(pr.MaxPathLen)(p)
+		}
+		p.lifetime = pr.Lifetime
+	}
+}
+
+// The profiles built in, following the baseline requirements of CA/Browser Forum and RFC 5280.
+var (
+	serverProfile = Profile{
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		Lifetime:    90 * day,
+	}
+	clientProfile = Profile{
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		Lifetime:    365 * day,
+	}
+	rootCAProfile = Profile{
+		KeyUsage:   x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		IsCA:       true,
+		MaxPathLen: -1,
+		Lifetime:   20 * 365 * day,
+	}
+	codeSigningProfile = Profile{
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		Lifetime:    365 * day,
+	}
+	emailProtectionProfile = Profile{
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection},
+		Lifetime:    365 * day,
+	}
+	ocspSigningProfile = Profile{
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+		Lifetime:    30 * day,
+	}
+	timestampingProfile = Profile{
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+		Lifetime:    3 * 365 * day,
+	}
+)
+
+func intermediateCAProfile(pathLen int) Profile {
+	return Profile{
+		KeyUsage:   x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		IsCA:       true,
+		MaxPathLen: pathLen,
+		Lifetime:   5 * 365 * day,
+	}
+}
+
+// ServerProfile returns an option of the profile of TLS servers, valid for 90 days.
+func ServerProfile() Option {
+	return serverProfile.Option()
+}
+
+// ClientProfile returns an option of the profile of TLS clients, valid for a year.
+func ClientProfile() Option {
+	return clientProfile.Option()
+}
+
+// RootCAProfile returns an option of the profile of root CAs, valid for 20 years.
+func RootCAProfile() Option {
+	return rootCAProfile.Option()
+}
+
+// IntermediateCAProfile returns an option of the profile of intermediate CAs, valid for 5 years.
+// pathLen is the maximum number of the intermediate CAs following it, negative means no limit.
+func IntermediateCAProfile(pathLen int) Option {
+	return intermediateCAProfile(pathLen).Option()
+}
+
+// CodeSigningProfile returns an option of the profile of code signing, valid for a year.
+func CodeSigningProfile() Option {
+	return codeSigningProfile.Option()
+}
+
+// EmailProtectionProfile returns an option of the profile of S/MIME, valid for a year.
+func EmailProtectionProfile() Option {
+	return emailProtectionProfile.Option()
+}
+
+// OCSPSigningProfile returns an option of the profile of delegated OCSP responders, valid for 30 days.
+func OCSPSigningProfile() Option {
+	return ocspSigningProfile.Option()
+}
+
+// TimestampingProfile returns an option of the profile of time stamping authorities, valid for 3 years.
+func TimestampingProfile() Option {
+	return timestampingProfile.Option()
+}
+
+// ErrUnknownProfile represents the profile is not registered.
+var ErrUnknownProfile = errors.New("unknown profile")
+
+var profiles = struct {
+	sync.RWMutex
+	m map[string]Profile
+}{
+	m: map[string]Profile{
+		"server":           serverProfile,
+		"client":           clientProfile,
+		"root-ca":          rootCAProfile,
+		"intermediate-ca":  intermediateCAProfile(0),
+		"code-signing":     codeSigningProfile,
+		"email-protection": emailProtectionProfile,
+		"ocsp-signing":     ocspSigningProfile,
+		"timestamping":     timestampingProfile,
+	},
+}
+
+// RegisterProfile registers the profile by name, replacing the one registered by the same name.
+// The profiles built in are server, client, root-ca, intermediate-ca, code-signing, email-protection,
+// ocsp-signing and timestamping.
+func RegisterProfile(name string, profile Profile) {
+	profiles.Lock()
+	defer profiles.Unlock()
+	profiles.m[name] = profile
+}
+
+// NamedProfile returns the profile registered by name.
+func NamedProfile(name string) (Profile, bool) {
+	profiles.RLock()
+	defer profiles.RUnlock()
+	profile, ok := profiles.m[name]
+	profile.ExtKeyUsage = append([]x509.ExtKeyUsage(nil), profile.ExtKeyUsage...)
+	return profile, ok
+}
+
+// ProfileNames returns the names of the registered profiles in order.
+func ProfileNames() []string {
+	profiles.RLock()
+	defer profiles.RUnlock()
+	names := make([]string, 0, len(profiles.m))
+	for name := range profiles.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UseProfile returns an option of applying the profile registered by name.
+func UseProfile(name string) Option {
+	return func(p *param) {
+		profile, ok := NamedProfile(name)
+		if !ok {
+			p.err = fmt.Errorf("%w: %q", ErrUnknownProfile, name)
+			return
+		}
+		profile.Option()(p)
+	}
+}
+
+// MaxPathLen returns an option of setting the maximum number of the intermediate CAs following the CA.
+// Negative means no limit.
+func MaxPathLen(n int) Option {
+	return func(p *param) {
+		if n < 0 {
+			p.maxPathLen, p.maxPathLenZero = -1, false
+			return
+		}
+		p.maxPathLen, p.maxPathLenZero = n, n == 0
+	}
+}
@@ -0,0 +1,92 @@
+package cert4now_test
+
+import (
+	"crypto/elliptic"
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func TestProfile(t *testing.T) {
+	root, err := cert4now.Generate(cert4now.RootCAProfile(), cert4now.CommonName("Root CA"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := parseLeaf(t, root); !c.IsCA || c.MaxPathLen != -1 || c.KeyUsage&x509.KeyUsageCertSign == 0 || len(c.ExtKeyUsage) != 0 {
+		t.Errorf("unexpected root CA %+v", c)
+	}
+	if got := parseLeaf(t, root).NotAfter.Sub(parseLeaf(t, root).NotBefore); got != 20*365*24*time.Hour {
+		t.Errorf("want 20 years, got %v", got)
+	}
+
+	ca, err := cert4now.Generate(cert4now.IntermediateCAProfile(0), cert4now.CommonName("Intermediate CA"), cert4now.Authority(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := parseLeaf(t, ca); !c.IsCA || c.MaxPathLen != 0 || !c.MaxPathLenZero {
+		t.Errorf("unexpected intermediate CA %+v", c)
+	}
+
+	leaf, err := cert4now.Generate(cert4now.ServerProfile(), cert4now.Authority(ca), cert4now.Names("www.example.com"), cert4now.MaxPathLen(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := parseLeaf(t, leaf); c.IsCA || !c.BasicConstraintsValid || c.MaxPathLen > 0 || len(c.ExtKeyUsage) != 1 || c.ExtKeyUsage[0] != x509.ExtKeyUsageServerAuth {
+		t.Errorf("unexpected server %+v", c)
+	}
+	if got := parseLeaf(t, leaf).NotAfter.Sub(parseLeaf(t, leaf).NotBefore); got != 90*24*time.Hour {
+		t.Errorf("want 90 days, got %v", got)
+	}
+
+	cases := []struct {
+		Option cert4now.Option
+		Usage  x509.ExtKeyUsage
+	}{
+		{cert4now.ClientProfile(), x509.ExtKeyUsageClientAuth},
+		{cert4now.CodeSigningProfile(), x509.ExtKeyUsageCodeSigning},
+		{cert4now.EmailProtectionProfile(), x509.ExtKeyUsageEmailProtection},
+		{cert4now.OCSPSigningProfile(), x509.ExtKeyUsageOCSPSigning},
+		{cert4now.TimestampingProfile(), x509.ExtKeyUsageTimeStamping},
+	}
+	for _, c := range cases {
+		cert, err := cert4now.Generate(c.Option, cert4now.Authority(ca), cert4now.ECDSA(elliptic.P256()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := parseLeaf(t, cert).ExtKeyUsage; len(got) != 1 || got[0] != c.Usage {
+			t.Errorf("want %v, got %v", c.Usage, got)
+		}
+	}
+}
+
+func TestProfile_register(t *testing.T) {
+	profile, ok := cert4now.NamedProfile("server")
+	if !ok {
+		t.Fatal("server profile is not registered")
+	}
+	profile.ExtKeyUsage = append(profile.ExtKeyUsage, x509.ExtKeyUsageClientAuth)
+	profile.Lifetime = 7 * 24 * time.Hour
+	cert4now.RegisterProfile("test-mtls", profile)
+
+	cert, err := cert4now.Generate(cert4now.UseProfile("test-mtls"), cert4now.Names("localhost"), cert4now.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parseLeaf(t, cert).ExtKeyUsage; len(got) != 2 {
+		t.Errorf("want serverAuth and clientAuth, got %v", got)
+	}
+	if got := parseLeaf(t, cert).NotAfter.Sub(parseLeaf(t, cert).NotBefore); got != 24*time.Hour {
+		t.Errorf("want AddDate to override the lifetime, got %v", got)
+	}
+	if again, _ := cert4now.NamedProfile("server"); len(again.ExtKeyUsage) != 1 {
+		t.Errorf("server profile is modified: %v", again.ExtKeyUsage)
+	}
+
+	_, err = cert4now.Generate(cert4now.UseProfile("no-such-profile"))
+	if !errors.Is(err, cert4now.ErrUnknownProfile) {
+		t.Errorf("want ErrUnknownProfile, got %v", err)
+	}
+}
@@ -0,0 +1,244 @@
+package cert4now
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// The protocol of the remote signing is JSON over HTTP.
+//
+//	GET  {base}/keys/{id}       returns {"keyId": id, "publicKey": PKIX public key in base64}
+//	POST {base}/keys/{id}/sign  takes {"digest": base64, "hash": "SHA-256", "pss": false, "saltLength": 0}
+//	                            returns {"signature": base64}
+//
+// The hash is the name of crypto.Hash, empty for Ed25519 signing the message as is.
+// The errors are returned with the status code and {"error": message}.
+
+type remoteKey struct {
+	KeyID     string `json:"keyId"`
+	PublicKey []byte `json:"publicKey"`
+}
+
+type remoteSignRequest struct {
+	Digest     []byte `json:"digest"`
+	Hash       string `json:"hash,omitempty"`
+	PSS        bool   `json:"pss,omitempty"`
+	SaltLength int    `json:"saltLength,omitempty"`
+}
+
+type remoteSignResponse struct {
+	Signature []byte `json:"signature"`
+}
+
+type remoteError struct {
+	Error string `json:"error"`
+}
+
+var remoteHashes = []crypto.Hash{crypto.SHA256, crypto.SHA384, crypto.SHA512, crypto.SHA1}
+
+func parseRemoteHash(name string) (crypto.Hash, error) {
+	if name == "" {
+		return 0, nil
+	}
+	for _, h := range remoteHashes {
+		if h.String() == name {
+			return h, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported hash %q", name)
+}
+
+// SigningServer is http.Handler signing digests with the keys it holds for RemoteSigner,
+// a stand-in of a KMS or an HSM keeping the keys out of the process generating certificates.
+type SigningServer struct {
+	mu   sync.RWMutex
+	keys map[string]crypto.Signer
+}
+
+// NewSigningServer returns a new SigningServer without keys.
+func NewSigningServer() *SigningServer {
+	return &SigningServer{keys: make(map[string]crypto.Signer)}
+}
+
+// AddKey adds key identified by id to s.
+func (s *SigningServer) AddKey(id string, key crypto.Signer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[id] = key
+}
+
+// ServeHTTP serves the protocol of the remote signing.
+func (s *SigningServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/")
+	if !strings.HasPrefix(path, "keys/") {
+		writeRemoteError(w, http.StatusNotFound, "not found")
+		return
+	}
+	id, op := strings.TrimPrefix(path, "keys/"), ""
+	if i := strings.LastIndex(id, "/"); i >= 0 {
+		id, op = id[:i], id[i+1:]
+	}
+	if unescaped, err := url.PathUnescape(id); err == nil {
+		id = unescaped
+	}
+
+	s.mu.RLock()
+	key, ok := s.keys[id]
+	s.mu.RUnlock()
+	if !ok {
+		writeRemoteError(w, http.StatusNotFound, fmt.Sprintf("unknown key %q", id))
+		return
+	}
+
+	switch {
+	case op == "" && r.Method == http.MethodGet:
+		der, err := x509.MarshalPKIXPublicKey(key.Public())
+		if err != nil {
+			writeRemoteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeRemoteJSON(w, remoteKey{KeyID: id, PublicKey: der})
+
+	case op == "sign" && r.Method == http.MethodPost:
+		var req remoteSignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeRemoteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		hash, err := parseRemoteHash(req.Hash)
+		if err != nil {
+			writeRemoteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		var opts crypto.SignerOpts = hash
+		if req.PSS {
+			opts = &rsa.PSSOptions{SaltLength: req.SaltLength, Hash: hash}
+		}
+		sig, err := key.Sign(rand.Reader, req.Digest, opts)
+		if err != nil {
+			writeRemoteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeRemoteJSON(w, remoteSignResponse{Signature: sig})
+
+	default:
+		writeRemoteError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func writeRemoteJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeRemoteError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(remoteError{Error: msg})
+}
+
+// RemoteSigner is ContextSigner signing with the key held by a server of the remote signing such as SigningServer.
+// It is usable as the private key of the authority, for example
+//
+//	key, err := NewRemoteSigner(ctx, "http://127.0.0.1:8200", "root", nil)
+//	ca := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+//	cert, err := Generate(Authority(ca))
+type RemoteSigner struct {
+	keyURL string
+	client *http.Client
+	public crypto.PublicKey
+}
+
+var _ ContextSigner = (*RemoteSigner)(nil)
+
+// NewRemoteSigner returns RemoteSigner of the key identified by keyID on the server of baseURL,
+// fetching the public key of it. client is http.DefaultClient if nil.
+func NewRemoteSigner(ctx context.Context, baseURL, keyID string, client *http.Client) (*RemoteSigner, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	s := &RemoteSigner{
+		keyURL: strings.TrimSuffix(baseURL, "/") + "/keys/" + url.PathEscape(keyID),
+		client: client,
+	}
+	var key remoteKey
+	if err := s.do(ctx, http.MethodGet, s.keyURL, nil, &key); err != nil {
+		return nil, err
+	}
+	pub, err := x509.ParsePKIXPublicKey(key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	s.public = pub
+	return s, nil
+}
+
+// Public returns the public key of the remote key.
+func (s *RemoteSigner) Public() crypto.PublicKey {
+	return s.public
+}
+
+// Sign signs digest with the remote key.
+func (s *RemoteSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.SignContext(context.Background(), rand, digest, opts)
+}
+
+// SignContext signs digest with the remote key, canceling the request when ctx is done.
+func (s *RemoteSigner) SignContext(ctx context.Context, _ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	req := remoteSignRequest{Digest: digest}
+	if h := opts.HashFunc(); h != 0 {
+		req.Hash = h.String()
+	}
+	if pss, ok := opts.(*rsa.PSSOptions); ok {
+		req.PSS = true
+		req.SaltLength = pss.SaltLength
+	}
+	var resp remoteSignResponse
+	if err := s.do(ctx, http.MethodPost, s.keyURL+"/sign", req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Signature, nil
+}
+
+func (s *RemoteSigner) do(ctx context.Context, method, target string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		p, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(p)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e remoteError
+		if json.NewDecoder(resp.Body).Decode(&e) != nil || e.Error == "" {
+			e.Error = resp.Status
+		}
+		return fmt.Errorf("remote signer: %s", e.Error)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
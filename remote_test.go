@@ -0,0 +1,66 @@
+package cert4now_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/elliptic"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func TestRemoteSigner(t *testing.T) {
+	server := cert4now.NewSigningServer()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	cases := []struct {
+		Name    string
+		Options []cert4now.Option
+	}{
+		{"rsa", nil},
+		{"rsa pss", []cert4now.Option{cert4now.RSAPSS()}},
+		{"ecdsa", []cert4now.Option{cert4now.ECDSA(elliptic.P384())}},
+		{"ed25519", []cert4now.Option{cert4now.Ed25519()}},
+	}
+	for _, c := range cases {
+		ca := generateCA(t, append([]cert4now.Option{cert4now.CommonName(c.Name)}, c.Options...)...)
+		server.AddKey(c.Name+"/ca", ca.PrivateKey.(crypto.Signer))
+
+		key, err := cert4now.NewRemoteSigner(context.Background(), ts.URL, c.Name+"/ca", ts.Client())
+		if err != nil {
+			t.Fatalf("%s: %v", c.Name, err)
+		}
+		remote := tls.Certificate{Certificate: ca.Certificate, PrivateKey: key}
+
+		options := append([]cert4now.Option{cert4now.Authority(remote), cert4now.Names("localhost")}, c.Options...)
+		cert, err := cert4now.Generate(options...)
+		if err != nil {
+			t.Fatalf("%s: %v", c.Name, err)
+		}
+		if err := parseLeaf(t, cert).CheckSignatureFrom(parseLeaf(t, ca)); err != nil {
+			t.Errorf("%s: %v", c.Name, err)
+		}
+		if c.Name == "rsa pss" && parseLeaf(t, cert).SignatureAlgorithm != x509.SHA256WithRSAPSS {
+			t.Errorf("%s: unexpected signature algorithm %v", c.Name, parseLeaf(t, cert).SignatureAlgorithm)
+		}
+	}
+
+	if _, err := cert4now.NewRemoteSigner(context.Background(), ts.URL, "unknown", ts.Client()); err == nil {
+		t.Error("want error of the unknown key")
+	}
+
+	key, err := cert4now.NewRemoteSigner(context.Background(), ts.URL, "rsa/ca", ts.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := key.SignContext(ctx, nil, make([]byte, 32), crypto.SHA256); !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+}
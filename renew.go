@@ -0,0 +1,103 @@
+package cert4now
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+)
+
+// oidsRepresented are the extensions generated from the options or bound to the authority,
+// the others are copied as they are by renewal.
+var oidsRepresented = append(append([]asn1.ObjectIdentifier{
+	{2, 5, 29, 15}, // key usage
+	{2, 5, 29, 17}, // subject alternative name
+	{2, 5, 29, 19}, // basic constraints
+	{2, 5, 29, 37}, // extended key usage
+}, oidsGenerated...), oidsIssuerBound...)
+
+// Renew returns the certificate reissued from cert with the new validity of the same lifetime from now,
+// keeping the key, the subject, the subject alternative names, the usages and the other extensions of cert.
+// The certificate is signed by authority, or self signed if authority has no certificate.
+// The private key of cert is kept if any, otherwise the certificate has no private key like CertificateRequest.
+//
+// The options override the properties of cert, such as NotAfter, DNSNamesReset and KeyUsage.
+// The subject alternative names other than the DNS names, the email addresses, the IP addresses and the URIs are not kept,
+// neither are the authority information access, the CRL distribution points and the signed certificate timestamps.
+func Renew(cert tls.Certificate, authority tls.Certificate, options ...Option) (tls.Certificate, error) {
+	leaf, err := leafOf(cert)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	renew := func(p *param) {
+		if signer, ok := cert.PrivateKey.(crypto.Signer); ok {
+			Signer(signer)(p)
+		} else {
+			p.publicKey = leaf.PublicKey
+			p.keyType = keyTypeOf(leaf.PublicKey)
+		}
+		renewal(leaf)(p)
+		p.subjectKeyID = leaf.SubjectKeyId
+	}
+	return Generate(renewOptions(renew, authority, options)...)
+}
+
+// Rekey returns the certificate reissued from cert like Renew, but with a fresh private key of the same type as cert.
+func Rekey(cert tls.Certificate, authority tls.Certificate, options ...Option) (tls.Certificate, error) {
+	leaf, err := leafOf(cert)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	rekey := func(p *param) {
+		keyTypeOption(keyTypeOf(leaf.PublicKey))(p)
+		renewal(leaf)(p)
+	}
+	return Generate(renewOptions(rekey, authority, options)...)
+}
+
+func renewOptions(renew Option, authority tls.Certificate, options []Option) []Option {
+	options = append([]Option{renew}, options...)
+	if len(authority.Certificate) > 0 {
+		options = append(options, Authority(authority))
+	}
+	return options
+}
+
+// renewal returns an option of issuing the certificate of the same subject, names, usages, lifetime and extensions as cert.
+func renewal(cert *x509.Certificate) Option {
+	return func(p *param) {
+		subject := cert.Subject
+		p.subject = &subject
+		p.rawSubject = cert.RawSubject
+		p.lifetime = cert.NotAfter.Sub(cert.NotBefore)
+		p.keyUsage = cert.KeyUsage
+		p.keyUsageSet = false
+		p.extKeyUsage = cert.ExtKeyUsage
+		p.unknownExtKeyUsage = cert.UnknownExtKeyUsage
+		p.basicConstraintsValid = cert.BasicConstraintsValid
+		p.isCA = cert.IsCA
+		p.maxPathLen = cert.MaxPathLen
+		p.maxPathLenZero = cert.MaxPathLenZero
+		p.dnsNames = cert.DNSNames
+		p.emailAddresses = cert.EmailAddresses
+		p.ipAddresses = cert.IPAddresses
+		p.uris = cert.URIs
+		p.extraExtensions = extensionsExcept(cert.Extensions, oidsRepresented)
+	}
+}
+
+// keyTypeOption returns the option of generating the private key of k.
+func keyTypeOption(k keyType) Option {
+	switch k.algorithm {
+	case x509.RSA:
+		return RSA(k.bits)
+	case x509.ECDSA:
+		return ECDSA(k.curve)
+	case x509.Ed25519:
+		return Ed25519()
+	}
+	return func(p *param) {
+		p.keyType = k
+		p.err = &UnsupportedKeyError{Key: k.String()}
+	}
+}
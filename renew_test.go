@@ -0,0 +1,206 @@
+package cert4now_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takumakei/go-cert4now"
+)
+
+func TestRenew(t *testing.T) {
+	ca := generateCA(t, cert4now.CommonName("CA"))
+	old, err := cert4now.Generate(
+		cert4now.Authority(ca),
+		cert4now.ECDSA(elliptic.P384()),
+		cert4now.SubjectString("CN=www.example.com,O=Acme"),
+		cert4now.Names("www.example.com", "192.0.2.1"),
+		cert4now.ExtKeyUsage(x509.ExtKeyUsageServerAuth),
+		cert4now.NotBefore(time.Now().Add(-24*time.Hour)),
+		cert4now.AddDate(0, 0, 30),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := cert4now.Renew(old, ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.PrivateKey != old.PrivateKey {
+		t.Error("private key is not kept")
+	}
+	got, want := parseLeaf(t, cert), parseLeaf(t, old)
+	if !bytes.Equal(got.RawSubjectPublicKeyInfo, want.RawSubjectPublicKeyInfo) {
+		t.Error("public key differs")
+	}
+	if !bytes.Equal(got.RawSubject, want.RawSubject) || !bytes.Equal(got.SubjectKeyId, want.SubjectKeyId) {
+		t.Error("subject differs")
+	}
+	if got.SerialNumber.Cmp(want.SerialNumber) == 0 {
+		t.Error("serial number is not renewed")
+	}
+	if got.NotBefore.Before(time.Now().Add(-time.Minute)) {
+		t.Errorf("NotBefore is not renewed: %v", got.NotBefore)
+	}
+	if d := got.NotAfter.Sub(got.NotBefore) - want.NotAfter.Sub(want.NotBefore); d < -time.Second || time.Second < d {
+		t.Errorf("lifetime differs by %v", d)
+	}
+	if diff := cmp.Diff(want.DNSNames, got.DNSNames); diff != "" {
+		t.Errorf("DNSNames (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want.IPAddresses, got.IPAddresses); diff != "" {
+		t.Errorf("IPAddresses (-want +got):\n%s", diff)
+	}
+	if got.KeyUsage != want.KeyUsage {
+		t.Errorf("want KeyUsage %v, got %v", want.KeyUsage, got.KeyUsage)
+	}
+	if diff := cmp.Diff(want.ExtKeyUsage, got.ExtKeyUsage); diff != "" {
+		t.Errorf("ExtKeyUsage (-want +got):\n%s", diff)
+	}
+
+	// The options override the properties of the certificate.
+	cert, err = cert4now.Renew(old, ca, cert4now.DNSNamesReset("api.example.com"), cert4now.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"api.example.com"}, parseLeaf(t, cert).DNSNames); diff != "" {
+		t.Errorf("DNSNames (-want +got):\n%s", diff)
+	}
+	if got := parseLeaf(t, cert).NotAfter.Sub(parseLeaf(t, cert).NotBefore); got != 7*24*time.Hour {
+		t.Errorf("want 7 days, got %v", got)
+	}
+
+	// The certificate without the private key is renewed without the private key.
+	cert, err = cert4now.Renew(tls.Certificate{Certificate: old.Certificate}, ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.PrivateKey != nil || !bytes.Equal(parseLeaf(t, cert).RawSubjectPublicKeyInfo, want.RawSubjectPublicKeyInfo) {
+		t.Error("unexpected key")
+	}
+}
+
+func TestRenew_issuerBound(t *testing.T) {
+	ca := generateCA(t, cert4now.CommonName("CA"))
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom := pkix.Extension{Id: asn1.ObjectIdentifier{1, 2, 3, 4}, Value: []byte{0x05, 0x00}}
+	sct := pkix.Extension{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}, Value: []byte{0x04, 0x00}}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "www.example.com"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().AddDate(0, 0, 30),
+		DNSNames:              []string{"www.example.com"},
+		OCSPServer:            []string{"http://ocsp.example.com"},
+		IssuingCertificateURL: []string{"http://example.com/ca.crt"},
+		CRLDistributionPoints: []string{"http://example.com/ca.crl"},
+		ExtraExtensions:       []pkix.Extension{custom, sct},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parseLeaf(t, ca), key.Public(), ca.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := cert4now.Renew(tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := parseLeaf(t, cert)
+	if len(got.OCSPServer) != 0 || len(got.IssuingCertificateURL) != 0 || len(got.CRLDistributionPoints) != 0 {
+		t.Errorf("extensions bound to the authority are kept: %v %v %v", got.OCSPServer, got.IssuingCertificateURL, got.CRLDistributionPoints)
+	}
+	found := false
+	for _, ext := range got.Extensions {
+		if ext.Id.Equal(sct.Id) {
+			t.Error("signed certificate timestamps are kept")
+		}
+		if ext.Id.Equal(custom.Id) {
+			found = bytes.Equal(ext.Value, custom.Value)
+		}
+	}
+	if !found {
+		t.Error("custom extension is not kept")
+	}
+}
+
+func TestRenew_selfSigned(t *testing.T) {
+	root := generateCA(t, cert4now.CommonName("Root CA"), cert4now.MaxPathLen(1))
+	leaf, err := cert4now.Generate(cert4now.Authority(root), cert4now.Names("www.example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renewed, err := cert4now.Renew(root, tls.Certificate{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parseLeaf(t, renewed).IsCA || parseLeaf(t, renewed).MaxPathLen != 1 {
+		t.Errorf("basic constraints differ: %v %v", parseLeaf(t, renewed).IsCA, parseLeaf(t, renewed).MaxPathLen)
+	}
+	if !bytes.Equal(parseLeaf(t, renewed).RawIssuer, parseLeaf(t, renewed).RawSubject) {
+		t.Error("not self signed")
+	}
+
+	// The certificates issued by the root are valid under the renewed root.
+	if _, err := cert4now.Verify(leaf, []tls.Certificate{renewed}, cert4now.VerifyName("www.example.com")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRekey(t *testing.T) {
+	ca := generateCA(t, cert4now.CommonName("CA"))
+	for _, key := range []cert4now.Option{cert4now.ECDSA(elliptic.P384()), cert4now.Ed25519(), cert4now.RSA(2048)} {
+		old, err := cert4now.Generate(cert4now.Authority(ca), key, cert4now.Names("www.example.com"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := cert4now.Rekey(old, ca)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, want := parseLeaf(t, cert), parseLeaf(t, old)
+		if got.PublicKeyAlgorithm != want.PublicKeyAlgorithm || bytes.Equal(got.RawSubjectPublicKeyInfo, want.RawSubjectPublicKeyInfo) {
+			t.Errorf("%v: public key is not renewed with the same type", want.PublicKeyAlgorithm)
+		}
+		if pub, ok := got.PublicKey.(*ecdsa.PublicKey); ok && pub.Curve != elliptic.P384() {
+			t.Errorf("want P-384, got %v", pub.Curve.Params().Name)
+		}
+		if bytes.Equal(got.SubjectKeyId, want.SubjectKeyId) {
+			t.Error("subject key identifier is not renewed")
+		}
+		if !bytes.Equal(got.RawSubject, want.RawSubject) {
+			t.Error("subject differs")
+		}
+		if diff := cmp.Diff(want.DNSNames, got.DNSNames); diff != "" {
+			t.Errorf("DNSNames (-want +got):\n%s", diff)
+		}
+		if _, err := tls.X509KeyPair(pemOf(t, cert)); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func pemOf(t *testing.T, cert tls.Certificate) ([]byte, []byte) {
+	t.Helper()
+	var c, k bytes.Buffer
+	if err := cert4now.WriteCertificate(&c, cert); err != nil {
+		t.Fatal(err)
+	}
+	if err := cert4now.WritePrivateKey(&k, cert); err != nil {
+		t.Fatal(err)
+	}
+	return c.Bytes(), k.Bytes()
+}
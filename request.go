@@ -0,0 +1,50 @@
+package cert4now
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+)
+
+// GenerateRequest generates a new private key and a certificate signing request of it in DER.
+// Only the options about the subject, the subject alternative names, the private key and the signature algorithm take effect.
+func GenerateRequest(options ...Option) (csr []byte, key crypto.Signer, err error) {
+	p := &param{}
+	err = p.apply(options...)
+	if err != nil {
+		return
+	}
+
+	key, err = p.newSigner()
+	if err != nil {
+		return
+	}
+
+	signatureAlgorithm, err := p.signatureAlgorithmFor(key.Public())
+	if err != nil {
+		return
+	}
+
+	template := &x509.CertificateRequest{
+		SignatureAlgorithm: signatureAlgorithm,
+
+		Subject:        *p.subject,
+		RawSubject:     p.rawSubject,
+		DNSNames:       p.dnsNames,
+		EmailAddresses: p.emailAddresses,
+		IPAddresses:    p.ipAddresses,
+	}
+
+	csr, err = x509.CreateCertificateRequest(rand.Reader, template, key)
+	return
+}
+
+// WriteCertificateRequest writes the certificate signing request in DER into w in PEM format.
+func WriteCertificateRequest(w io.Writer, csr []byte) error {
+	return pem.Encode(w, &pem.Block{
+		Type:  "CERTIFICATE REQUEST",
+		Bytes: csr,
+	})
+}
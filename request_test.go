@@ -0,0 +1,72 @@
+package cert4now_test
+
+import (
+	"crypto"
+	"crypto/x509"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/takumakei/go-cert4now"
+)
+
+func TestGenerateRequest(t *testing.T) {
+	ca, err := cert4now.Generate(
+		cert4now.CommonName("Root CA"),
+		cert4now.KeyUsage(x509.KeyUsageCertSign),
+		cert4now.IsCA(true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, key, err := cert4now.GenerateRequest(
+		cert4now.CommonName("www.example.com"),
+		cert4now.Names("www.example.com", "192.0.2.1"),
+		cert4now.Ed25519(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := cert4now.Generate(cert4now.Authority(ca), cert4now.CertificateRequest(csr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.PrivateKey != nil {
+		t.Fatal("certificate issued for a request must not have the private key")
+	}
+	if diff := cmp.Diff(key.Public(), parseLeaf(t, cert).PublicKey); diff != "" {
+		t.Fatalf("-want +got\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"www.example.com"}, parseLeaf(t, cert).DNSNames); diff != "" {
+		t.Fatalf("-want +got\n%s", diff)
+	}
+	if err := parseLeaf(t, cert).CheckSignatureFrom(parseLeaf(t, ca)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cert4now.Generate(cert4now.CertificateRequest(csr)); err != cert4now.ErrNoAuthority {
+		t.Fatalf("want ErrNoAuthority, got %v", err)
+	}
+}
+
+func TestGenerateRequest_signatureAlgorithm(t *testing.T) {
+	der, _, err := cert4now.GenerateRequest(cert4now.RSAPSS(), cert4now.SignatureHash(crypto.SHA384))
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := x509.SHA384WithRSAPSS; csr.SignatureAlgorithm != want {
+		t.Errorf("want %v, got %v", want, csr.SignatureAlgorithm)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,215 @@
+package cert4now
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SerialPolicy assigns the serial numbers of the certificates.
+type SerialPolicy interface {
+	// NextSerial returns the serial number of the certificate signed by the key of issuerKeyID,
+	// that is the subject key identifier of the authority, or of the certificate itself if self signed.
+	NextSerial(ctx context.Context, issuerKeyID []byte) (*big.Int, error)
+}
+
+// UseSerialPolicy returns an option of assigning the serial number by policy.
+// The default policy is RandomSerial. SerialNumber takes precedence over the policy.
+func UseSerialPolicy(policy SerialPolicy) Option {
+	return func(p *param) {
+		p.serialPolicy = policy
+	}
+}
+
+// randomSerial is SerialPolicy of RandomSerial.
+type randomSerial struct{}
+
+// RandomSerial returns SerialPolicy of the random serial numbers of 159 bits,
+// that is the longest positive number in 20 octets of RFC 5280.
+func RandomSerial() SerialPolicy {
+	return randomSerial{}
+}
+
+var maxSerial = new(big.Int).Lsh(big.NewInt(1), 159)
+
+func (randomSerial) NextSerial(ctx context.Context, _ []byte) (*big.Int, error) {
+	for {
+		serial, err := rand.Int(rand.Reader, maxSerial)
+		if err != nil {
+			return nil, err
+		}
+		if serial.Sign() > 0 {
+			return serial, nil
+		}
+	}
+}
+
+// timeSerial is SerialPolicy of TimeSerial.
+type timeSerial struct {
+	now func() time.Time
+}
+
+// TimeSerial returns SerialPolicy of the serial numbers prefixed by the time of the issuance in nanoseconds,
+// followed by 64 random bits. The serial numbers sort in the order of the issuance.
+func TimeSerial() SerialPolicy {
+	return timeSerial{now: time.Now}
+}
+
+func (s timeSerial) NextSerial(ctx context.Context, _ []byte) (*big.Int, error) {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(s.now().UnixNano()))
+	if _, err := rand.Read(b[8:]); err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b[:]), nil
+}
+
+// sequentialSerial is SerialPolicy of SequentialSerial and SequentialSerialFile.
+type sequentialSerial struct {
+	mu       sync.Mutex
+	filename func(issuerKeyID []byte) string
+}
+
+// SequentialSerial returns SerialPolicy of the serial numbers counting up from 1 for each authority.
+// The counters persist in dir, in the files named by the subject key identifier of the authority in hex.
+func SequentialSerial(dir string) SerialPolicy {
+	return &sequentialSerial{
+		filename: func(issuerKeyID []byte) string {
+			return filepath.Join(dir, hex.EncodeToString(issuerKeyID)+".serial")
+		},
+	}
+}
+
+// SequentialSerialFile returns SerialPolicy of the serial numbers counting up from 1,
+// persisting the next serial number in hex in the file of filename regardless of the authority.
+func SequentialSerialFile(filename string) SerialPolicy {
+	return &sequentialSerial{
+		filename: func([]byte) string {
+			return filename
+		},
+	}
+}
+
+func (s *sequentialSerial) NextSerial(ctx context.Context, issuerKeyID []byte) (*big.Int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	filename := s.filename(issuerKeyID)
+	serial := big.NewInt(1)
+	p, err := os.ReadFile(filename)
+	switch {
+	case err == nil:
+		if _, ok := serial.SetString(strings.TrimSpace(string(p)), 16); !ok || serial.Sign() <= 0 {
+			return nil, fmt.Errorf("%s: invalid serial number", filename)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return nil, err
+	}
+	next := new(big.Int).Add(serial, big.NewInt(1))
+	if err := writeFileAtomic(filename, []byte(next.Text(16)+"\n"), 0644); err != nil {
+		return nil, err
+	}
+	return serial, nil
+}
+
+// ErrSerialCollision represents the serial number is already used by the authority.
+var ErrSerialCollision = errors.New("serial number is already used by the authority")
+
+// SerialRegistry records the serial numbers used by each authority to detect the collisions.
+type SerialRegistry interface {
+	// Register records serial used by the authority of issuerKeyID,
+	// or returns ErrSerialCollision if already recorded.
+	Register(ctx context.Context, issuerKeyID []byte, serial *big.Int) error
+}
+
+// MemorySerialRegistry is SerialRegistry keeping the serial numbers in memory.
+type MemorySerialRegistry struct {
+	mu   sync.Mutex
+	used map[string]bool
+}
+
+var _ SerialRegistry = (*MemorySerialRegistry)(nil)
+
+// NewMemorySerialRegistry returns a new empty MemorySerialRegistry.
+func NewMemorySerialRegistry() *MemorySerialRegistry {
+	return &MemorySerialRegistry{used: make(map[string]bool)}
+}
+
+// Register records serial used by the authority of issuerKeyID.
+func (r *MemorySerialRegistry) Register(ctx context.Context, issuerKeyID []byte, serial *big.Int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	key := hex.EncodeToString(issuerKeyID) + ":" + serial.Text(16)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.used[key] {
+		return ErrSerialCollision
+	}
+	r.used[key] = true
+	return nil
+}
+
+// UseSerialRegistry returns an option of checking the serial number against registry.
+// The serial number assigned by the policy is assigned again on the collision.
+func UseSerialRegistry(registry SerialRegistry) Option {
+	return func(p *param) {
+		p.serialRegistry = registry
+	}
+}
+
+// serialAttempts is the number of the serial numbers tried on the collisions.
+const serialAttempts = 8
+
+// assignSerial returns the serial number of the certificate signed by the key of issuerKeyID.
+func (p *param) assignSerial(ctx context.Context, issuerKeyID []byte) (*big.Int, error) {
+	if p.serialNumber != nil {
+		if p.serialRegistry != nil {
+			if err := p.serialRegistry.Register(ctx, issuerKeyID, p.serialNumber); err != nil {
+				return nil, err
+			}
+		}
+		return p.serialNumber, nil
+	}
+
+	policy := p.serialPolicy
+	if policy == nil {
+		policy = RandomSerial()
+	}
+	var err error
+	for i := 0; i < serialAttempts; i++ {
+		var serial *big.Int
+		serial, err = policy.NextSerial(ctx, issuerKeyID)
+		if err != nil {
+			return nil, err
+		}
+		if p.serialRegistry == nil {
+			return serial, nil
+		}
+		err = p.serialRegistry.Register(ctx, issuerKeyID, serial)
+		if err == nil {
+			return serial, nil
+		}
+		if !errors.Is(err, ErrSerialCollision) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
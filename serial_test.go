@@ -0,0 +1,112 @@
+package cert4now_test
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func TestRandomSerial(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		cert, err := cert4now.Generate(cert4now.Ed25519())
+		if err != nil {
+			t.Fatal(err)
+		}
+		serial := parseLeaf(t, cert).SerialNumber
+		if serial.Sign() <= 0 || serial.BitLen() > 159 {
+			t.Errorf("unexpected serial number %x", serial)
+		}
+		if serial.BitLen() > 64 {
+			return
+		}
+	}
+	t.Error("serial numbers are too short")
+}
+
+func TestTimeSerial(t *testing.T) {
+	policy := cert4now.TimeSerial()
+	prev, err := policy.NextSerial(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		serial, err := policy.NextSerial(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if serial.Cmp(prev) <= 0 {
+			t.Errorf("want %x after %x", serial, prev)
+		}
+		prev = serial
+	}
+}
+
+func TestSequentialSerial(t *testing.T) {
+	dir := t.TempDir()
+	policy := cert4now.UseSerialPolicy(cert4now.SequentialSerial(dir))
+	ca1 := generateCA(t, cert4now.Ed25519())
+	ca2 := generateCA(t, cert4now.Ed25519())
+
+	for _, want := range []int64{1, 2, 3} {
+		cert, err := cert4now.Generate(policy, cert4now.Authority(ca1), cert4now.Ed25519())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := parseLeaf(t, cert).SerialNumber; got.Cmp(big.NewInt(want)) != 0 {
+			t.Errorf("want %d, got %d", want, got)
+		}
+	}
+	cert, err := cert4now.Generate(policy, cert4now.Authority(ca2), cert4now.Ed25519())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parseLeaf(t, cert).SerialNumber; got.Int64() != 1 {
+		t.Errorf("want 1 of the other authority, got %d", got)
+	}
+
+	p, err := os.ReadFile(filepath.Join(dir, hex.EncodeToString(parseLeaf(t, ca1).SubjectKeyId)+".serial"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "4\n"; string(p) != want {
+		t.Errorf("want %q, got %q", want, p)
+	}
+}
+
+func TestSerialRegistry(t *testing.T) {
+	registry := cert4now.NewMemorySerialRegistry()
+	ca1 := generateCA(t, cert4now.Ed25519())
+	ca2 := generateCA(t, cert4now.Ed25519())
+	options := func(ca cert4now.Option) []cert4now.Option {
+		return []cert4now.Option{ca, cert4now.Ed25519(), cert4now.SerialNumber(big.NewInt(42)), cert4now.UseSerialRegistry(registry)}
+	}
+
+	if _, err := cert4now.Generate(options(cert4now.Authority(ca1))...); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cert4now.Generate(options(cert4now.Authority(ca2))...); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cert4now.Generate(options(cert4now.Authority(ca1))...); !errors.Is(err, cert4now.ErrSerialCollision) {
+		t.Errorf("want ErrSerialCollision, got %v", err)
+	}
+
+	// The sequential serial numbers skip the ones already used.
+	policy := cert4now.SequentialSerialFile(filepath.Join(t.TempDir(), "serial"))
+	if err := registry.Register(context.Background(), parseLeaf(t, ca1).SubjectKeyId, big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := cert4now.Generate(cert4now.Authority(ca1), cert4now.Ed25519(), cert4now.UseSerialPolicy(policy), cert4now.UseSerialRegistry(registry))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parseLeaf(t, cert).SerialNumber; got.Int64() != 2 {
+		t.Errorf("want 2, got %d", got)
+	}
+}
@@ -0,0 +1,95 @@
+package cert4now
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+)
+
+// SignatureAlgorithm returns an option of setting the algorithm to sign the certificate.
+// The algorithm must match the key of the authority, or the key of the certificate if self signed.
+func SignatureAlgorithm(alg x509.SignatureAlgorithm) Option {
+	return func(p *param) {
+		p.signatureAlgorithm = alg
+	}
+}
+
+// SignatureHash returns an option of setting the hash function to sign the certificate,
+// that is crypto.SHA256, crypto.SHA384 or crypto.SHA512.
+// The signature algorithm is chosen by the key of the authority with the hash.
+func SignatureHash(h crypto.Hash) Option {
+	return func(p *param) {
+		p.signatureHash = h
+	}
+}
+
+// RSAPSS returns an option of signing the certificate with RSASSA-PSS instead of PKCS #1 v1.5.
+// It takes effect only if the key of the authority is RSA. The hash is SHA-256 unless SignatureHash tells otherwise.
+func RSAPSS() Option {
+	return func(p *param) {
+		p.rsaPSS = true
+	}
+}
+
+// SignatureAlgorithmError represents the signature algorithm can not be used with the key.
+type SignatureAlgorithmError struct {
+	Algorithm x509.SignatureAlgorithm
+	Hash      crypto.Hash
+	Key       string
+}
+
+func (e *SignatureAlgorithmError) Error() string {
+	if e.Algorithm == x509.UnknownSignatureAlgorithm {
+		return fmt.Sprintf("hash %v can not sign with the key %s", e.Hash, e.Key)
+	}
+	return fmt.Sprintf("signature algorithm %v can not sign with the key %s", e.Algorithm, e.Key)
+}
+
+type signatureAlgorithmDetails struct {
+	algorithm x509.SignatureAlgorithm
+	key       x509.PublicKeyAlgorithm
+	hash      crypto.Hash
+	pss       bool
+}
+
+var signatureAlgorithms = []signatureAlgorithmDetails{
+	{x509.SHA256WithRSA, x509.RSA, crypto.SHA256, false},
+	{x509.SHA384WithRSA, x509.RSA, crypto.SHA384, false},
+	{x509.SHA512WithRSA, x509.RSA, crypto.SHA512, false},
+	{x509.SHA256WithRSAPSS, x509.RSA, crypto.SHA256, true},
+	{x509.SHA384WithRSAPSS, x509.RSA, crypto.SHA384, true},
+	{x509.SHA512WithRSAPSS, x509.RSA, crypto.SHA512, true},
+	{x509.ECDSAWithSHA256, x509.ECDSA, crypto.SHA256, false},
+	{x509.ECDSAWithSHA384, x509.ECDSA, crypto.SHA384, false},
+	{x509.ECDSAWithSHA512, x509.ECDSA, crypto.SHA512, false},
+	{x509.PureEd25519, x509.Ed25519, 0, false},
+}
+
+// signatureAlgorithmFor returns the signature algorithm to sign with the key of pub,
+// or x509.UnknownSignatureAlgorithm to let x509 choose it by the key.
+func (p *param) signatureAlgorithmFor(pub crypto.PublicKey) (x509.SignatureAlgorithm, error) {
+	key := keyTypeOf(pub)
+	if p.signatureAlgorithm != x509.UnknownSignatureAlgorithm {
+		for _, v := range signatureAlgorithms {
+			if v.algorithm == p.signatureAlgorithm && v.key == key.algorithm {
+				return v.algorithm, nil
+			}
+		}
+		return 0, &SignatureAlgorithmError{Algorithm: p.signatureAlgorithm, Key: key.String()}
+	}
+
+	if p.signatureHash == 0 && !(p.rsaPSS && key.algorithm == x509.RSA) {
+		return x509.UnknownSignatureAlgorithm, nil
+	}
+	hash := p.signatureHash
+	if hash == 0 {
+		hash = crypto.SHA256
+	}
+	pss := p.rsaPSS && key.algorithm == x509.RSA
+	for _, v := range signatureAlgorithms {
+		if v.key == key.algorithm && v.hash == hash && v.pss == pss {
+			return v.algorithm, nil
+		}
+	}
+	return 0, &SignatureAlgorithmError{Hash: hash, Key: key.String()}
+}
@@ -0,0 +1,63 @@
+package cert4now_test
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/x509"
+	"errors"
+	"testing"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func TestSignatureAlgorithm(t *testing.T) {
+	rsaCA := generateCA(t, cert4now.CommonName("RSA CA"))
+	ecCA := generateCA(t, cert4now.CommonName("ECDSA CA"), cert4now.ECDSA(elliptic.P384()))
+
+	cases := []struct {
+		Name    string
+		Options []cert4now.Option
+		Want    x509.SignatureAlgorithm
+	}{
+		{"rsa default", []cert4now.Option{cert4now.Authority(rsaCA)}, x509.SHA256WithRSA},
+		{"rsa pss", []cert4now.Option{cert4now.Authority(rsaCA), cert4now.RSAPSS()}, x509.SHA256WithRSAPSS},
+		{"rsa pss sha512", []cert4now.Option{cert4now.Authority(rsaCA), cert4now.RSAPSS(), cert4now.SignatureHash(crypto.SHA512)}, x509.SHA512WithRSAPSS},
+		{"rsa sha384", []cert4now.Option{cert4now.Authority(rsaCA), cert4now.SignatureHash(crypto.SHA384)}, x509.SHA384WithRSA},
+		{"ecdsa default", []cert4now.Option{cert4now.Authority(ecCA)}, x509.ECDSAWithSHA384},
+		{"ecdsa sha256", []cert4now.Option{cert4now.Authority(ecCA), cert4now.SignatureHash(crypto.SHA256)}, x509.ECDSAWithSHA256},
+		{"ecdsa ignores pss", []cert4now.Option{cert4now.Authority(ecCA), cert4now.RSAPSS()}, x509.ECDSAWithSHA384},
+		{"explicit", []cert4now.Option{cert4now.Authority(rsaCA), cert4now.SignatureAlgorithm(x509.SHA384WithRSAPSS)}, x509.SHA384WithRSAPSS},
+		{"self signed", []cert4now.Option{cert4now.ECDSA(elliptic.P256()), cert4now.SignatureAlgorithm(x509.ECDSAWithSHA512)}, x509.ECDSAWithSHA512},
+	}
+	for _, c := range cases {
+		cert, err := cert4now.Generate(append(c.Options, cert4now.Names("localhost"))...)
+		if err != nil {
+			t.Errorf("%s: %v", c.Name, err)
+			continue
+		}
+		if got := parseLeaf(t, cert).SignatureAlgorithm; got != c.Want {
+			t.Errorf("%s: want %v, got %v", c.Name, c.Want, got)
+		}
+		if len(cert.Certificate) > 1 {
+			issuer, err := x509.ParseCertificate(cert.Certificate[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := parseLeaf(t, cert).CheckSignatureFrom(issuer); err != nil {
+				t.Errorf("%s: %v", c.Name, err)
+			}
+		}
+	}
+
+	for _, options := range [][]cert4now.Option{
+		{cert4now.Authority(ecCA), cert4now.SignatureAlgorithm(x509.SHA256WithRSAPSS)},
+		{cert4now.Ed25519(), cert4now.SignatureHash(crypto.SHA256)},
+		{cert4now.Authority(rsaCA), cert4now.SignatureHash(crypto.SHA1)},
+	} {
+		_, err := cert4now.Generate(options...)
+		var sigErr *cert4now.SignatureAlgorithmError
+		if !errors.As(err, &sigErr) {
+			t.Errorf("want SignatureAlgorithmError, got %v", err)
+		}
+	}
+}
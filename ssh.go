@@ -0,0 +1,71 @@
+package cert4now
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/binary"
+	"math/big"
+)
+
+// marshalAuthorizedKey encodes pub in the wire format of RFC 4253, RFC 5656 and RFC 8709,
+// then returns it as a line of OpenSSH authorized_keys without the comment.
+func marshalAuthorizedKey(pub crypto.PublicKey) ([]byte, error) {
+	var name string
+	var wire []byte
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		name = "ssh-rsa"
+		wire = sshString(wire, []byte(name))
+		wire = sshMPInt(wire, big.NewInt(int64(k.E)))
+		wire = sshMPInt(wire, k.N)
+
+	case *ecdsa.PublicKey:
+		var curve string
+		switch k.Curve {
+		case elliptic.P256():
+			curve = "nistp256"
+		case elliptic.P384():
+			curve = "nistp384"
+		case elliptic.P521():
+			curve = "nistp521"
+		default:
+			return nil, ErrUnsupportedKeyFormat
+		}
+		name = "ecdsa-sha2-" + curve
+		wire = sshString(wire, []byte(name))
+		wire = sshString(wire, []byte(curve))
+		wire = sshString(wire, elliptic.Marshal(k.Curve, k.X, k.Y))
+
+	case ed25519.PublicKey:
+		name = "ssh-ed25519"
+		wire = sshString(wire, []byte(name))
+		wire = sshString(wire, k)
+
+	default:
+		return nil, ErrUnsupportedKeyFormat
+	}
+
+	line := make([]byte, 0, len(name)+1+base64.StdEncoding.EncodedLen(len(wire)))
+	line = append(line, name...)
+	line = append(line, ' ')
+	line = append(line, base64.StdEncoding.EncodeToString(wire)...)
+	return line, nil
+}
+
+func sshString(b, s []byte) []byte {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(s)))
+	return append(append(b, n[:]...), s...)
+}
+
+func sshMPInt(b []byte, v *big.Int) []byte {
+	p := v.Bytes()
+	if len(p) > 0 && p[0]&0x80 != 0 {
+		p = append([]byte{0}, p...)
+	}
+	return sshString(b, p)
+}
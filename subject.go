@@ -0,0 +1,407 @@
+package cert4now
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// subjectAttribute is an attribute type of the subject known by name.
+type subjectAttribute struct {
+	name string
+	oid  asn1.ObjectIdentifier
+	ia5  bool
+}
+
+// subjectAttributes are the attribute types known by name, the first name of an OID is used to format.
+var subjectAttributes = []subjectAttribute{
+	{name: "CN", oid: asn1.ObjectIdentifier{2, 5, 4, 3}},
+	{name: "commonName", oid: asn1.ObjectIdentifier{2, 5, 4, 3}},
+	{name: "SN", oid: asn1.ObjectIdentifier{2, 5, 4, 4}},
+	{name: "surname", oid: asn1.ObjectIdentifier{2, 5, 4, 4}},
+	{name: "SERIALNUMBER", oid: asn1.ObjectIdentifier{2, 5, 4, 5}},
+	{name: "C", oid: asn1.ObjectIdentifier{2, 5, 4, 6}},
+	{name: "countryName", oid: asn1.ObjectIdentifier{2, 5, 4, 6}},
+	{name: "L", oid: asn1.ObjectIdentifier{2, 5, 4, 7}},
+	{name: "localityName", oid: asn1.ObjectIdentifier{2, 5, 4, 7}},
+	{name: "ST", oid: asn1.ObjectIdentifier{2, 5, 4, 8}},
+	{name: "stateOrProvinceName", oid: asn1.ObjectIdentifier{2, 5, 4, 8}},
+	{name: "STREET", oid: asn1.ObjectIdentifier{2, 5, 4, 9}},
+	{name: "O", oid: asn1.ObjectIdentifier{2, 5, 4, 10}},
+	{name: "organizationName", oid: asn1.ObjectIdentifier{2, 5, 4, 10}},
+	{name: "OU", oid: asn1.ObjectIdentifier{2, 5, 4, 11}},
+	{name: "organizationalUnitName", oid: asn1.ObjectIdentifier{2, 5, 4, 11}},
+	{name: "title", oid: asn1.ObjectIdentifier{2, 5, 4, 12}},
+	{name: "postalCode", oid: asn1.ObjectIdentifier{2, 5, 4, 17}},
+	{name: "GN", oid: asn1.ObjectIdentifier{2, 5, 4, 42}},
+	{name: "givenName", oid: asn1.ObjectIdentifier{2, 5, 4, 42}},
+	{name: "DC", oid: asn1.ObjectIdentifier{0, 9, 2342, 19200300, 100, 1, 25}, ia5: true},
+	{name: "UID", oid: asn1.ObjectIdentifier{0, 9, 2342, 19200300, 100, 1, 1}},
+	{name: "emailAddress", oid: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1}, ia5: true},
+	{name: "E", oid: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1}, ia5: true},
+}
+
+// SubjectSyntaxError represents the subject string is malformed.
+type SubjectSyntaxError struct {
+	Subject string
+	Offset  int
+	Reason  string
+}
+
+func (e *SubjectSyntaxError) Error() string {
+	return fmt.Sprintf("invalid subject %q at %d: %s", e.Subject, e.Offset, e.Reason)
+}
+
+// SubjectString returns an option of setting the subject parsed by ParseSubject.
+// The subject is encoded as given, keeping the order, the multi-valued RDNs and the attribute types unknown to pkix.Name.
+// Subject or CommonName given after this option sets the subject of pkix.Name instead.
+func SubjectString(s string) Option {
+	return func(p *param) {
+		rdns, err := ParseSubject(s)
+		if err != nil {
+			p.err = err
+			return
+		}
+		raw, err := asn1.Marshal(rdns)
+		if err != nil {
+			p.err = err
+			return
+		}
+		p.subject = subjectName(rdns)
+		p.rawSubject = raw
+	}
+}
+
+// subjectName returns pkix.Name of rdns, holding the attributes of no field of pkix.Name in ExtraNames.
+func subjectName(rdns pkix.RDNSequence) *pkix.Name {
+	var name pkix.Name
+	name.FillFromRDNSequence(&rdns)
+	for _, atv := range name.Names {
+		if _, ok := atv.Value.(string); !ok || !nameField(atv.Type) {
+			name.ExtraNames = append(name.ExtraNames, atv)
+		}
+	}
+	return &name
+}
+
+// nameField returns true if the attribute of oid is filled into a field of pkix.Name.
+func nameField(oid asn1.ObjectIdentifier) bool {
+	if len(oid) != 4 || !oid[:3].Equal(asn1.ObjectIdentifier{2, 5, 4}) {
+		return false
+	}
+	switch oid[3] {
+	case 3, 5, 6, 7, 8, 9, 10, 11, 17:
+		return true
+	}
+	return false
+}
+
+// ParseSubject parses the subject in the string representation of RFC 4514 such as "CN=foo,O=Acme,C=JP",
+// or in the form of OpenSSL such as "/C=JP/O=Acme/CN=foo" if s starts with a slash.
+//
+// The RDNs of RFC 4514 are in the reverse order of the encoding, while the ones of OpenSSL are in the same order.
+// The multi-valued RDNs are joined by '+'. The attribute type is a name such as CN, O and emailAddress,
+// or the OID in the dotted decimal form. The special characters of the value are escaped by a backslash,
+// and a backslash followed by two hex digits is a byte of UTF-8. In RFC 4514, the value starting with '#'
+// is the BER encoding in hex.
+func ParseSubject(s string) (pkix.RDNSequence, error) {
+	openSSL := strings.HasPrefix(s, "/")
+	sep, start := byte(','), 0
+	if openSSL {
+		sep, start = '/', 1
+	}
+	sp := &subjectParser{s: s, i: start, sep: sep, openSSL: openSSL}
+
+	var rdns pkix.RDNSequence
+	for {
+		rdn, err := sp.rdn()
+		if err != nil {
+			return nil, err
+		}
+		rdns = append(rdns, rdn)
+		if sp.i >= len(s) {
+			break
+		}
+		sp.i++ // sep
+	}
+
+	if !openSSL {
+		for i, j := 0, len(rdns)-1; i < j; i, j = i+1, j-1 {
+			rdns[i], rdns[j] = rdns[j], rdns[i]
+		}
+	}
+	return rdns, nil
+}
+
+type subjectParser struct {
+	s       string
+	i       int
+	sep     byte
+	openSSL bool
+}
+
+func (sp *subjectParser) errorf(format string, a ...interface{}) error {
+	return &SubjectSyntaxError{Subject: sp.s, Offset: sp.i, Reason: fmt.Sprintf(format, a...)}
+}
+
+func (sp *subjectParser) skipSpaces() {
+	for sp.i < len(sp.s) && sp.s[sp.i] == ' ' {
+		sp.i++
+	}
+}
+
+// rdn parses the attributes up to sep or the end.
+func (sp *subjectParser) rdn() (pkix.RelativeDistinguishedNameSET, error) {
+	var rdn pkix.RelativeDistinguishedNameSET
+	for {
+		atv, err := sp.attribute()
+		if err != nil {
+			return nil, err
+		}
+		rdn = append(rdn, atv)
+		if sp.i >= len(sp.s) || sp.s[sp.i] == sp.sep {
+			return rdn, nil
+		}
+		sp.i++ // '+'
+	}
+}
+
+func (sp *subjectParser) attribute() (pkix.AttributeTypeAndValue, error) {
+	sp.skipSpaces()
+	eq := strings.IndexByte(sp.s[sp.i:], '=')
+	if eq < 0 {
+		return pkix.AttributeTypeAndValue{}, sp.errorf("missing '='")
+	}
+	name := strings.TrimSpace(sp.s[sp.i : sp.i+eq])
+	attr, err := parseAttributeType(name)
+	if err != nil {
+		return pkix.AttributeTypeAndValue{}, sp.errorf("%v", err)
+	}
+	sp.i += eq + 1
+	sp.skipSpaces()
+
+	if !sp.openSSL && sp.i < len(sp.s) && sp.s[sp.i] == '#' {
+		value, err := sp.berValue()
+		if err != nil {
+			return pkix.AttributeTypeAndValue{}, err
+		}
+		return pkix.AttributeTypeAndValue{Type: attr.oid, Value: value}, nil
+	}
+
+	value, err := sp.stringValue()
+	if err != nil {
+		return pkix.AttributeTypeAndValue{}, err
+	}
+	if attr.ia5 {
+		der, err := asn1.MarshalWithParams(value, "ia5")
+		if err != nil {
+			return pkix.AttributeTypeAndValue{}, sp.errorf("%s is not IA5String: %v", name, err)
+		}
+		return pkix.AttributeTypeAndValue{Type: attr.oid, Value: asn1.RawValue{FullBytes: der}}, nil
+	}
+	return pkix.AttributeTypeAndValue{Type: attr.oid, Value: value}, nil
+}
+
+// berValue parses the value of '#' followed by the BER encoding in hex.
+func (sp *subjectParser) berValue() (asn1.RawValue, error) {
+	start := sp.i + 1
+	end := start
+	for end < len(sp.s) && sp.s[end] != sp.sep && sp.s[end] != '+' && sp.s[end] != ' ' {
+		end++
+	}
+	der, err := hex.DecodeString(sp.s[start:end])
+	if err != nil {
+		return asn1.RawValue{}, sp.errorf("invalid hex: %v", err)
+	}
+	var value asn1.RawValue
+	if rest, err := asn1.Unmarshal(der, &value); err != nil || len(rest) > 0 {
+		return asn1.RawValue{}, sp.errorf("invalid BER encoding")
+	}
+	sp.i = end
+	sp.skipSpaces()
+	if sp.i < len(sp.s) && sp.s[sp.i] != sp.sep && sp.s[sp.i] != '+' {
+		return asn1.RawValue{}, sp.errorf("unexpected %q", sp.s[sp.i])
+	}
+	return asn1.RawValue{FullBytes: der}, nil
+}
+
+// stringValue parses the string value up to an unescaped sep, '+' or the end,
+// removing the unescaped trailing spaces.
+func (sp *subjectParser) stringValue() (string, error) {
+	var b []byte
+	keep := 0 // the length of b up to the last escaped or non-space byte
+	for sp.i < len(sp.s) {
+		c := sp.s[sp.i]
+		switch {
+		case c == sp.sep || c == '+':
+			return sp.utf8(b[:keep])
+		case c == '\\':
+			if sp.i+1 >= len(sp.s) {
+				return "", sp.errorf("trailing backslash")
+			}
+			if sp.i+2 < len(sp.s) && isHex(sp.s[sp.i+1]) && isHex(sp.s[sp.i+2]) {
+				v, _ := hex.DecodeString(sp.s[sp.i+1 : sp.i+3])
+				b = append(b, v[0])
+				sp.i += 3
+			} else {
+				b = append(b, sp.s[sp.i+1])
+				sp.i += 2
+			}
+			keep = len(b)
+			continue
+		case !sp.openSSL && (c == '"' || c == ';' || c == '<' || c == '>'):
+			return "", sp.errorf("unescaped %q", c)
+		}
+		b = append(b, c)
+		if c != ' ' {
+			keep = len(b)
+		}
+		sp.i++
+	}
+	return sp.utf8(b[:keep])
+}
+
+func (sp *subjectParser) utf8(b []byte) (string, error) {
+	if !utf8.Valid(b) {
+		return "", sp.errorf("invalid UTF-8")
+	}
+	return string(b), nil
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// parseAttributeType returns the attribute type of name, or of the OID in the dotted decimal form optionally prefixed by "OID.".
+func parseAttributeType(name string) (subjectAttribute, error) {
+	for _, attr := range subjectAttributes {
+		if strings.EqualFold(attr.name, name) {
+			return attr, nil
+		}
+	}
+	s := name
+	if len(s) > 4 && strings.EqualFold(s[:4], "OID.") {
+		s = s[4:]
+	}
+	var oid asn1.ObjectIdentifier
+	for _, arc := range strings.Split(s, ".") {
+		var n int
+		if arc == "" || len(arc) > 9 || (len(arc) > 1 && arc[0] == '0') {
+			return subjectAttribute{}, fmt.Errorf("unknown attribute type %q", name)
+		}
+		for _, c := range arc {
+			if c < '0' || '9' < c {
+				return subjectAttribute{}, fmt.Errorf("unknown attribute type %q", name)
+			}
+			n = n*10 + int(c-'0')
+		}
+		oid = append(oid, n)
+	}
+	if len(oid) < 2 {
+		return subjectAttribute{}, fmt.Errorf("unknown attribute type %q", name)
+	}
+	for _, attr := range subjectAttributes {
+		if attr.oid.Equal(oid) {
+			return attr, nil
+		}
+	}
+	return subjectAttribute{name: name, oid: oid}, nil
+}
+
+// FormatSubject returns the string representation of RFC 4514 of rdns, the reverse order of the encoding.
+func FormatSubject(rdns pkix.RDNSequence) string {
+	rdnStrings := make([]string, 0, len(rdns))
+	for i := len(rdns) - 1; i >= 0; i-- {
+		rdnStrings = append(rdnStrings, formatRDN(rdns[i], escapeRFC4514))
+	}
+	return strings.Join(rdnStrings, ",")
+}
+
+// FormatSubjectOpenSSL returns the form of OpenSSL of rdns such as "/C=JP/O=Acme/CN=foo", the same order as the encoding.
+func FormatSubjectOpenSSL(rdns pkix.RDNSequence) string {
+	var sb strings.Builder
+	for _, rdn := range rdns {
+		sb.WriteByte('/')
+		sb.WriteString(formatRDN(rdn, escapeOpenSSL))
+	}
+	return sb.String()
+}
+
+func formatRDN(rdn pkix.RelativeDistinguishedNameSET, escape func(string) string) string {
+	atvs := make([]string, len(rdn))
+	for i, atv := range rdn {
+		atvs[i] = attributeName(atv.Type) + "=" + formatAttributeValue(atv.Value, escape)
+	}
+	return strings.Join(atvs, "+")
+}
+
+func attributeName(oid asn1.ObjectIdentifier) string {
+	for _, attr := range subjectAttributes {
+		if attr.oid.Equal(oid) {
+			return attr.name
+		}
+	}
+	return oid.String()
+}
+
+// formatAttributeValue returns the string escaped, or '#' followed by the BER encoding in hex if not a string.
+func formatAttributeValue(value interface{}, escape func(string) string) string {
+	switch v := value.(type) {
+	case string:
+		return escape(v)
+	case asn1.RawValue:
+		var s string
+		if rest, err := asn1.Unmarshal(v.FullBytes, &s); err == nil && len(rest) == 0 {
+			return escape(s)
+		}
+		return "#" + hex.EncodeToString(v.FullBytes)
+	}
+	der, err := asn1.Marshal(value)
+	if err != nil {
+		return escape(fmt.Sprint(value))
+	}
+	return "#" + hex.EncodeToString(der)
+}
+
+func escapeRFC4514(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ',' || c == '+' || c == '"' || c == '\\' || c == '<' || c == '>' || c == ';':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case c == ' ' && (i == 0 || i == len(s)-1):
+			sb.WriteString("\\ ")
+		case c == '#' && i == 0:
+			sb.WriteString("\\#")
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&sb, "\\%02x", c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+func escapeOpenSSL(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '/' || c == '+' || c == '\\' || c == '=':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case c == ' ' && (i == 0 || i == len(s)-1):
+			sb.WriteString("\\ ")
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&sb, "\\%02x", c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
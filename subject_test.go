@@ -0,0 +1,137 @@
+package cert4now_test
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func TestParseSubject(t *testing.T) {
+	tests := []struct {
+		in      string
+		rfc     string
+		openSSL string
+	}{
+		{"CN=foo,O=Acme,OU=Eng,C=JP", "CN=foo,O=Acme,OU=Eng,C=JP", "/C=JP/OU=Eng/O=Acme/CN=foo"},
+		{"/C=JP/O=Acme/CN=foo", "CN=foo,O=Acme,C=JP", "/C=JP/O=Acme/CN=foo"},
+		{" cn = foo , o = Acme ", "CN=foo,O=Acme", "/O=Acme/CN=foo"},
+		{"CN=foo+UID=42,O=Acme", "CN=foo+UID=42,O=Acme", "/O=Acme/CN=foo+UID=42"},
+		{"/O=Acme/CN=foo+UID=42", "CN=foo+UID=42,O=Acme", "/O=Acme/CN=foo+UID=42"},
+		{`CN=a\,b\+c\\d\"e,O=Acme`, `CN=a\,b\+c\\d\"e,O=Acme`, `/O=Acme/CN=a,b\+c\\d"e`},
+		{`/O=a\/b/CN=c=d`, `CN=c=d,O=a/b`, `/O=a\/b/CN=c\=d`},
+		{`CN=\ foo\ `, `CN=\ foo\ `, `/CN=\ foo\ `},
+		{`CN=\e6\97\a5\e6\9c\ac`, "CN=日本", "/CN=日本"},
+		{"CN=#0c03666f6f", "CN=foo", "/CN=foo"},
+		{"1.2.3.4=x,OID.2.5.4.3=foo", "1.2.3.4=x,CN=foo", "/CN=foo/1.2.3.4=x"},
+		{"1.2.3.4=#020101", "1.2.3.4=#020101", "/1.2.3.4=#020101"},
+		{"emailAddress=foo@example.com,DC=example,DC=com", "emailAddress=foo@example.com,DC=example,DC=com", "/DC=com/DC=example/emailAddress=foo@example.com"},
+	}
+	for _, tt := range tests {
+		rdns, err := cert4now.ParseSubject(tt.in)
+		if err != nil {
+			t.Errorf("%q: %v", tt.in, err)
+			continue
+		}
+		if got := cert4now.FormatSubject(rdns); got != tt.rfc {
+			t.Errorf("%q: want %q, got %q", tt.in, tt.rfc, got)
+		}
+		if got := cert4now.FormatSubjectOpenSSL(rdns); got != tt.openSSL {
+			t.Errorf("%q: want %q, got %q", tt.in, tt.openSSL, got)
+		}
+		roundTrip := []string{tt.rfc}
+		if !strings.Contains(tt.rfc, "#") {
+			// The form of OpenSSL has no way to give the BER encoding.
+			roundTrip = append(roundTrip, tt.openSSL)
+		}
+		for _, s := range roundTrip {
+			again, err := cert4now.ParseSubject(s)
+			if err != nil {
+				t.Errorf("%q: %v", s, err)
+				continue
+			}
+			if cert4now.FormatSubject(again) != tt.rfc {
+				t.Errorf("%q does not round trip: %q", s, cert4now.FormatSubject(again))
+			}
+		}
+	}
+}
+
+func TestParseSubject_error(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"CN",
+		"/",
+		"XX=foo",
+		"1=foo",
+		"CN=foo,",
+		`CN=foo\`,
+		"CN=a;b",
+		"CN=#zz",
+		"CN=#0c03666f",
+		`CN=\ff`,
+		"E=日本",
+	} {
+		_, err := cert4now.ParseSubject(in)
+		var e *cert4now.SubjectSyntaxError
+		if !errors.As(err, &e) {
+			t.Errorf("%q: want SubjectSyntaxError, got %v", in, err)
+		}
+	}
+}
+
+func TestSubjectString(t *testing.T) {
+	const subject = "CN=foo+UID=42,OU=Eng,O=Acme,1.2.3.4=x,C=JP"
+	cert, err := cert4now.Generate(cert4now.Ed25519(), cert4now.SubjectString(subject))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rdns pkix.RDNSequence
+	if _, err := asn1.Unmarshal(parseLeaf(t, cert).RawSubject, &rdns); err != nil {
+		t.Fatal(err)
+	}
+	if got := cert4now.FormatSubject(rdns); got != subject {
+		t.Errorf("want %q, got %q", subject, got)
+	}
+	if !bytes.Equal(parseLeaf(t, cert).RawIssuer, parseLeaf(t, cert).RawSubject) {
+		t.Error("issuer of the self signed certificate differs from the subject")
+	}
+	if parseLeaf(t, cert).Subject.CommonName != "foo" || parseLeaf(t, cert).Subject.Organization[0] != "Acme" {
+		t.Errorf("unexpected subject %v", parseLeaf(t, cert).Subject)
+	}
+
+	// CommonName after SubjectString keeps the attributes other than the common name.
+	cert, err = cert4now.Generate(cert4now.Ed25519(), cert4now.SubjectString(subject), cert4now.CommonName("bar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parseLeaf(t, cert).Subject; got.CommonName != "bar" || got.Country[0] != "JP" || len(got.Names) != 6 {
+		t.Errorf("unexpected subject %v", got)
+	}
+
+	csr, _, err := cert4now.GenerateRequest(cert4now.Ed25519(), cert4now.SubjectString(subject))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := x509.ParseCertificateRequest(csr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asn1.Unmarshal(req.RawSubject, &rdns); err != nil {
+		t.Fatal(err)
+	}
+	if got := cert4now.FormatSubject(rdns); got != subject {
+		t.Errorf("want %q, got %q", subject, got)
+	}
+
+	_, err = cert4now.Generate(cert4now.SubjectString("CN"))
+	var e *cert4now.SubjectSyntaxError
+	if !errors.As(err, &e) {
+		t.Errorf("want SubjectSyntaxError, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package cert4now
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+var keyUsageNames = []struct {
+	name  string
+	usage x509.KeyUsage
+}{
+	{"digitalSignature", x509.KeyUsageDigitalSignature},
+	{"contentCommitment", x509.KeyUsageContentCommitment},
+	{"keyEncipherment", x509.KeyUsageKeyEncipherment},
+	{"dataEncipherment", x509.KeyUsageDataEncipherment},
+	{"keyAgreement", x509.KeyUsageKeyAgreement},
+	{"certSign", x509.KeyUsageCertSign},
+	{"crlSign", x509.KeyUsageCRLSign},
+	{"encipherOnly", x509.KeyUsageEncipherOnly},
+	{"decipherOnly", x509.KeyUsageDecipherOnly},
+}
+
+var extKeyUsageNames = []struct {
+	name  string
+	usage x509.ExtKeyUsage
+}{
+	{"any", x509.ExtKeyUsageAny},
+	{"serverAuth", x509.ExtKeyUsageServerAuth},
+	{"clientAuth", x509.ExtKeyUsageClientAuth},
+	{"codeSigning", x509.ExtKeyUsageCodeSigning},
+	{"emailProtection", x509.ExtKeyUsageEmailProtection},
+	{"ipsecEndSystem", x509.ExtKeyUsageIPSECEndSystem},
+	{"ipsecTunnel", x509.ExtKeyUsageIPSECTunnel},
+	{"ipsecUser", x509.ExtKeyUsageIPSECUser},
+	{"timeStamping", x509.ExtKeyUsageTimeStamping},
+	{"ocspSigning", x509.ExtKeyUsageOCSPSigning},
+}
+
+// ParseKeyUsage parses the names of key usages such as "digitalSignature" or "certSign",
+// then returns the union of them. The names are case insensitive.
+func ParseKeyUsage(names ...string) (x509.KeyUsage, error) {
+	var usage x509.KeyUsage
+next:
+	for _, name := range names {
+		for _, v := range keyUsageNames {
+			if strings.EqualFold(name, v.name) {
+				usage |= v.usage
+				continue next
+			}
+		}
+		return 0, fmt.Errorf("unknown key usage %q", name)
+	}
+	return usage, nil
+}
+
+// ParseExtKeyUsage parses the names of extended key usages such as "serverAuth" or "clientAuth".
+// The names are case insensitive.
+func ParseExtKeyUsage(names ...string) ([]x509.ExtKeyUsage, error) {
+	usage := make([]x509.ExtKeyUsage, 0, len(names))
+next:
+	for _, name := range names {
+		for _, v := range extKeyUsageNames {
+			if strings.EqualFold(name, v.name) {
+				usage = append(usage, v.usage)
+				continue next
+			}
+		}
+		return nil, fmt.Errorf("unknown extended key usage %q", name)
+	}
+	return usage, nil
+}
+
+// KeyUsageNames returns the names of the key usages set in usage.
+func KeyUsageNames(usage x509.KeyUsage) []string {
+	var names []string
+	for _, v := range keyUsageNames {
+		if usage&v.usage != 0 {
+			names = append(names, v.name)
+		}
+	}
+	return names
+}
+
+// ExtKeyUsageName returns the name of the extended key usage.
+func ExtKeyUsageName(usage x509.ExtKeyUsage) string {
+	for _, v := range extKeyUsageNames {
+		if usage == v.usage {
+			return v.name
+		}
+	}
+	return fmt.Sprintf("ExtKeyUsage(%d)", int(usage))
+}
@@ -0,0 +1,192 @@
+package cert4now
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors is the errors of the options found all at once.
+// Generate returns Errors only if more than one error is found.
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether any of the errors matches target, so that errors.Is examines each of them.
+func (e Errors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the errors that matches target, so that errors.As examines each of them.
+func (e Errors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// err returns nil if e is empty, the error if e has only one, or e otherwise.
+func (e Errors) err() error {
+	switch len(e) {
+	case 0:
+		return nil
+	case 1:
+		return e[0]
+	}
+	return e
+}
+
+// ErrEmptyAuthority represents the authority certificate has no certificate.
+var ErrEmptyAuthority = errors.New("authority has no certificate")
+
+// ErrInvalidValidity represents NotAfter is before NotBefore.
+var ErrInvalidValidity = errors.New("NotAfter is before NotBefore")
+
+// ErrInvalidSerialNumber represents the serial number is not positive.
+var ErrInvalidSerialNumber = errors.New("serial number must be positive")
+
+// UnsupportedKeyError represents the type of the key is not supported.
+type UnsupportedKeyError struct {
+	Key string
+}
+
+func (e *UnsupportedKeyError) Error() string {
+	return "unsupported key: " + e.Key
+}
+
+// InvalidNameError represents the DNS name is invalid.
+type InvalidNameError struct {
+	Name   string
+	Reason string
+}
+
+func (e *InvalidNameError) Error() string {
+	return fmt.Sprintf("invalid DNS name %q: %s", e.Name, e.Reason)
+}
+
+// keyType is the type of the key to generate or the key given.
+type keyType struct {
+	algorithm x509.PublicKeyAlgorithm
+	bits      int
+	curve     elliptic.Curve
+}
+
+func keyTypeOf(pub crypto.PublicKey) keyType {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return keyType{algorithm: x509.RSA, bits: k.N.BitLen()}
+	case *ecdsa.PublicKey:
+		return keyType{algorithm: x509.ECDSA, curve: k.Curve}
+	case ed25519.PublicKey:
+		return keyType{algorithm: x509.Ed25519}
+	}
+	return keyType{}
+}
+
+func (k keyType) String() string {
+	switch k.algorithm {
+	case x509.RSA:
+		return fmt.Sprintf("RSA %d bits", k.bits)
+	case x509.ECDSA:
+		if k.curve == nil {
+			return "ECDSA without curve"
+		}
+		return "ECDSA " + k.curve.Params().Name
+	case x509.Ed25519:
+		return "Ed25519"
+	}
+	return "unknown"
+}
+
+func (k keyType) supported() bool {
+	switch k.algorithm {
+	case x509.RSA:
+		return k.bits >= 1024
+	case x509.ECDSA:
+		switch k.curve {
+		case elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521():
+			return true
+		}
+	case x509.Ed25519:
+		return true
+	}
+	return false
+}
+
+// validate returns the errors of the parameters.
+func (p *param) validate() Errors {
+	var errs Errors
+	if p.notAfter.Before(p.notBefore) {
+		errs = append(errs, ErrInvalidValidity)
+	}
+	if p.serialNumber != nil && p.serialNumber.Sign() <= 0 {
+		errs = append(errs, ErrInvalidSerialNumber)
+	}
+	if !p.keyType.supported() {
+		errs = append(errs, &UnsupportedKeyError{Key: p.keyType.String()})
+	}
+	for _, name := range p.dnsNames {
+		if err := validateDNSName(name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// validateDNSName validates the syntax of the DNS name of RFC 1034, allowing a wildcard as the whole leftmost label.
+// The underscore is allowed, since it is common in the names of services.
+func validateDNSName(name string) error {
+	invalid := func(reason string) error {
+		return &InvalidNameError{Name: name, Reason: reason}
+	}
+	if len(name) > 253 {
+		return invalid("longer than 253 characters")
+	}
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		switch {
+		case label == "":
+			return invalid("empty label")
+		case len(label) > 63:
+			return invalid("label longer than 63 characters")
+		case strings.Contains(label, "*"):
+			if label != "*" {
+				return invalid("wildcard must be the whole label")
+			}
+			if i != 0 {
+				return invalid("wildcard must be the leftmost label")
+			}
+			if len(labels) < 3 {
+				return invalid("wildcard must be followed by two or more labels")
+			}
+			continue
+		case label[0] == '-' || label[len(label)-1] == '-':
+			return invalid("label starts or ends with a hyphen")
+		}
+		for _, c := range label {
+			if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+				return invalid(fmt.Sprintf("invalid character %q", c))
+			}
+		}
+	}
+	return nil
+}
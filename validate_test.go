@@ -0,0 +1,60 @@
+package cert4now_test
+
+import (
+	"crypto/elliptic"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func TestGenerate_validate(t *testing.T) {
+	now := time.Now()
+	_, err := cert4now.Generate(
+		cert4now.Authority(tls.Certificate{}),
+		cert4now.NotBefore(now),
+		cert4now.NotAfter(now.Add(-time.Hour)),
+		cert4now.SerialNumber(big.NewInt(-1)),
+		cert4now.ECDSA(elliptic.P256()),
+		cert4now.RSA(512),
+		cert4now.DNSNames("www.example.com", "-www.example.com", "*.example.com", "w*.example.com", "www.*.com", "*.com"),
+	)
+
+	var errs cert4now.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("want Errors, got %v", err)
+	}
+	for _, want := range []error{cert4now.ErrEmptyAuthority, cert4now.ErrInvalidValidity, cert4now.ErrInvalidSerialNumber} {
+		if !errors.Is(err, want) {
+			t.Errorf("want %v in %v", want, err)
+		}
+	}
+	var keyErr *cert4now.UnsupportedKeyError
+	if !errors.As(err, &keyErr) || keyErr.Key != "RSA 512 bits" {
+		t.Errorf("want UnsupportedKeyError of RSA 512 bits, got %v", keyErr)
+	}
+	var names []string
+	for _, err := range errs {
+		var nameErr *cert4now.InvalidNameError
+		if errors.As(err, &nameErr) {
+			names = append(names, nameErr.Name)
+		}
+	}
+	if want := "[-www.example.com w*.example.com www.*.com *.com]"; want != fmt.Sprint(names) {
+		t.Errorf("want %s, got %s", want, names)
+	}
+	if want := 8; len(errs) != want {
+		t.Errorf("want %d errors, got %d: %v", want, len(errs), errs)
+	}
+}
+
+func TestGenerate_validateSingle(t *testing.T) {
+	_, err := cert4now.Generate(cert4now.Authority(tls.Certificate{}))
+	if err != cert4now.ErrEmptyAuthority {
+		t.Errorf("want ErrEmptyAuthority, got %v", err)
+	}
+}
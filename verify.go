@@ -0,0 +1,314 @@
+package cert4now
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// VerifyOption represents an option for Verify.
+type VerifyOption func(*verifyParam)
+
+type verifyParam struct {
+	name   string
+	time   time.Time
+	usages []x509.ExtKeyUsage
+}
+
+// VerifyName returns an option of verifying the certificate is valid for the DNS name or the IP address.
+func VerifyName(name string) VerifyOption {
+	return func(p *verifyParam) {
+		p.name = name
+	}
+}
+
+// VerifyTime returns an option of verifying the certificate at t instead of now.
+func VerifyTime(t time.Time) VerifyOption {
+	return func(p *verifyParam) {
+		p.time = t
+	}
+}
+
+// VerifyExtKeyUsage returns an option of the extended key usages, any of which the certificate must be valid for.
+// The default is ExtKeyUsageServerAuth, and ExtKeyUsageAny accepts any.
+func VerifyExtKeyUsage(usage ...x509.ExtKeyUsage) VerifyOption {
+	return func(p *verifyParam) {
+		p.usages = usage
+	}
+}
+
+// ProblemKind is the kind of Problem.
+type ProblemKind string
+
+// The kinds of Problem.
+const (
+	ProblemExpired             ProblemKind = "expired"
+	ProblemNotYetValid         ProblemKind = "not yet valid"
+	ProblemHostname            ProblemKind = "hostname mismatch"
+	ProblemExtKeyUsage         ProblemKind = "wrong extended key usage"
+	ProblemMissingIntermediate ProblemKind = "missing intermediate"
+	ProblemUnknownAuthority    ProblemKind = "unknown authority"
+	ProblemIssuerMismatch      ProblemKind = "issuer name mismatch"
+	ProblemKeyIDMismatch       ProblemKind = "authority key id mismatch"
+	ProblemNotCA               ProblemKind = "issuer is not a CA"
+	ProblemKeyUsage            ProblemKind = "issuer lacks certSign"
+	ProblemBadSignature        ProblemKind = "bad signature"
+	ProblemOther               ProblemKind = "other"
+)
+
+// Problem is a diagnosis of a certificate failed in Verify.
+type Problem struct {
+	// Index is the position of the certificate in tls.Certificate.Certificate,
+	// or -1 for the certificates out of it such as the roots.
+	Index   int
+	Subject string
+	Kind    ProblemKind
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("[%d] %s: %s: %s", p.Index, p.Subject, p.Kind, p.Message)
+}
+
+// VerifyError is the error Verify returns, explaining which link of the chain failed and why.
+type VerifyError struct {
+	Problems []Problem
+
+	// Err is the error of x509.Certificate.Verify.
+	Err error
+}
+
+func (e *VerifyError) Error() string {
+	msgs := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		msgs[i] = p.String()
+	}
+	return "verification failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *VerifyError) Unwrap() error {
+	return e.Err
+}
+
+// Has reports whether e has a problem of kind.
+func (e *VerifyError) Has(kind ProblemKind) bool {
+	for _, p := range e.Problems {
+		if p.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Verify builds the chain from cert.Certificate up to one of roots, checking the name, the extended key usage and the time.
+// It returns the verified chain on success, or *VerifyError diagnosing the failure.
+func Verify(cert tls.Certificate, roots []tls.Certificate, options ...VerifyOption) ([]*x509.Certificate, error) {
+	p := &verifyParam{usages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}
+	for _, option := range options {
+		option(p)
+	}
+	if p.time.IsZero() {
+		p.time = time.Now()
+	}
+
+	if len(cert.Certificate) == 0 {
+		return nil, errors.New("no certificate to verify")
+	}
+	chain := make([]*x509.Certificate, len(cert.Certificate))
+	for i, der := range cert.Certificate {
+		c, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		chain[i] = c
+	}
+	var rootCerts []*x509.Certificate
+	for _, root := range roots {
+		if len(root.Certificate) == 0 {
+			continue
+		}
+		c, err := x509.ParseCertificate(root.Certificate[0])
+		if err != nil {
+			return nil, err
+		}
+		rootCerts = append(rootCerts, c)
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         x509.NewCertPool(),
+		Intermediates: x509.NewCertPool(),
+		CurrentTime:   p.time,
+		KeyUsages:     p.usages,
+	}
+	for _, c := range rootCerts {
+		opts.Roots.AddCert(c)
+	}
+	for _, c := range chain[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	verified, err := chain[0].Verify(opts)
+	if err == nil && p.name != "" {
+		err = chain[0].VerifyHostname(p.name)
+	}
+	if err == nil {
+		return verified[0], nil
+	}
+
+	problems := diagnose(chain, rootCerts, p)
+	if len(problems) == 0 {
+		problems = append(problems, Problem{Index: 0, Subject: chain[0].Subject.String(), Kind: ProblemOther, Message: err.Error()})
+	}
+	return nil, &VerifyError{Problems: problems, Err: err}
+}
+
+// diagnose walks the chain from the leaf to a root, then returns the problems found on the way.
+func diagnose(chain, roots []*x509.Certificate, p *verifyParam) []Problem {
+	var problems []Problem
+	index := func(c *x509.Certificate) int {
+		for i, v := range chain {
+			if v == c {
+				return i
+			}
+		}
+		return -1
+	}
+	report := func(c *x509.Certificate, kind ProblemKind, format string, a ...interface{}) {
+		problems = append(problems, Problem{
+			Index:   index(c),
+			Subject: c.Subject.String(),
+			Kind:    kind,
+			Message: fmt.Sprintf(format, a...),
+		})
+	}
+	isRoot := func(c *x509.Certificate) bool {
+		for _, r := range roots {
+			if bytes.Equal(r.Raw, c.Raw) {
+				return true
+			}
+		}
+		return false
+	}
+
+	leaf := chain[0]
+	if p.name != "" {
+		if err := leaf.VerifyHostname(p.name); err != nil {
+			var names []string
+			names = append(names, leaf.DNSNames...)
+			for _, ip := range leaf.IPAddresses {
+				names = append(names, ip.String())
+			}
+			if net.ParseIP(p.name) == nil && len(leaf.DNSNames) == 0 && leaf.Subject.CommonName != "" {
+				report(leaf, ProblemHostname, "%q is not valid since the certificate has no DNS names, the common name %q is not used", p.name, leaf.Subject.CommonName)
+			} else {
+				report(leaf, ProblemHostname, "%q is not in [%s]", p.name, strings.Join(names, ", "))
+			}
+		}
+	}
+
+	visited := make(map[*x509.Certificate]bool)
+	for c := leaf; c != nil && !visited[c]; {
+		visited[c] = true
+
+		if p.time.Before(c.NotBefore) {
+			report(c, ProblemNotYetValid, "valid from %s", c.NotBefore.Format(time.RFC3339))
+		}
+		if p.time.After(c.NotAfter) {
+			report(c, ProblemExpired, "expired at %s", c.NotAfter.Format(time.RFC3339))
+		}
+		if !extKeyUsageAllows(c, p.usages) {
+			var names []string
+			for _, v := range c.ExtKeyUsage {
+				names = append(names, ExtKeyUsageName(v))
+			}
+			var want []string
+			for _, v := range p.usages {
+				want = append(want, ExtKeyUsageName(v))
+			}
+			report(c, ProblemExtKeyUsage, "has [%s], wants any of [%s]", strings.Join(names, ", "), strings.Join(want, ", "))
+		}
+
+		if isRoot(c) {
+			break
+		}
+		selfSigned := bytes.Equal(c.RawIssuer, c.RawSubject) && c.CheckSignature(c.SignatureAlgorithm, c.RawTBSCertificate, c.Signature) == nil
+		if selfSigned {
+			report(c, ProblemUnknownAuthority, "self signed certificate is not in the roots")
+			break
+		}
+
+		issuer, byName := findIssuer(c, chain, roots)
+		if issuer == nil {
+			report(c, ProblemMissingIntermediate, "issuer %q with key id %s is not found in the chain or the roots", c.Issuer.String(), colonHex(c.AuthorityKeyId))
+			break
+		}
+
+		if !byName {
+			report(c, ProblemIssuerMismatch, "issuer is %q, but the certificate of the key id is %q", c.Issuer.String(), issuer.Subject.String())
+		}
+		if len(c.AuthorityKeyId) > 0 && len(issuer.SubjectKeyId) > 0 && !bytes.Equal(c.AuthorityKeyId, issuer.SubjectKeyId) {
+			report(c, ProblemKeyIDMismatch, "authority key id %s does not match subject key id %s of %q", colonHex(c.AuthorityKeyId), colonHex(issuer.SubjectKeyId), issuer.Subject.String())
+		}
+		if issuer.BasicConstraintsValid && !issuer.IsCA || !issuer.BasicConstraintsValid && issuer.Version == 3 {
+			report(issuer, ProblemNotCA, "basic constraints of %q does not allow issuing certificates", issuer.Subject.String())
+		}
+		if issuer.KeyUsage != 0 && issuer.KeyUsage&x509.KeyUsageCertSign == 0 {
+			report(issuer, ProblemKeyUsage, "key usage [%s] lacks certSign", strings.Join(KeyUsageNames(issuer.KeyUsage), ", "))
+		}
+		if err := issuer.CheckSignature(c.SignatureAlgorithm, c.RawTBSCertificate, c.Signature); err != nil {
+			report(c, ProblemBadSignature, "signature is not made by %q: %v", issuer.Subject.String(), err)
+		}
+
+		c = issuer
+	}
+
+	return problems
+}
+
+// findIssuer finds the issuer of c in chain and roots, preferring the one matching both the name and the key id.
+// byName is false if the issuer is found only by the key id.
+func findIssuer(c *x509.Certificate, chain, roots []*x509.Certificate) (issuer *x509.Certificate, byName bool) {
+	var byKeyID *x509.Certificate
+	for _, list := range [][]*x509.Certificate{chain, roots} {
+		for _, v := range list {
+			if v == c {
+				continue
+			}
+			nameMatch := bytes.Equal(c.RawIssuer, v.RawSubject)
+			keyMatch := len(c.AuthorityKeyId) > 0 && bytes.Equal(c.AuthorityKeyId, v.SubjectKeyId)
+			switch {
+			case nameMatch && (keyMatch || len(c.AuthorityKeyId) == 0):
+				return v, true
+			case nameMatch && issuer == nil:
+				issuer = v
+			case keyMatch && byKeyID == nil:
+				byKeyID = v
+			}
+		}
+	}
+	if issuer != nil {
+		return issuer, true
+	}
+	return byKeyID, false
+}
+
+func extKeyUsageAllows(c *x509.Certificate, usages []x509.ExtKeyUsage) bool {
+	if len(c.ExtKeyUsage) == 0 && len(c.UnknownExtKeyUsage) == 0 {
+		return true
+	}
+	for _, want := range usages {
+		if want == x509.ExtKeyUsageAny {
+			return true
+		}
+		for _, have := range c.ExtKeyUsage {
+			if have == x509.ExtKeyUsageAny || have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
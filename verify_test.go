@@ -0,0 +1,99 @@
+package cert4now_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/takumakei/go-cert4now"
+)
+
+func generateCA(t *testing.T, options ...cert4now.Option) tls.Certificate {
+	t.Helper()
+	defaults := []cert4now.Option{
+		cert4now.AddDate(1, 0, 0),
+		cert4now.KeyUsage(x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign),
+		cert4now.ExtKeyUsage(),
+		cert4now.IsCA(true),
+	}
+	ca, err := cert4now.Generate(append(defaults, options...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca
+}
+
+func TestVerify(t *testing.T) {
+	root := generateCA(t, cert4now.CommonName("Root CA"))
+	ca := generateCA(t, cert4now.CommonName("Intermediate CA"), cert4now.Authority(root))
+	leaf, err := cert4now.Generate(cert4now.Authority(ca), cert4now.Names("www.example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain, err := cert4now.Verify(leaf, []tls.Certificate{root}, cert4now.VerifyName("www.example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != 3 || chain[2].Subject.CommonName != "Root CA" {
+		t.Fatalf("unexpected chain %v", chain)
+	}
+
+	// The intermediate CA regenerated with the same name but a different key.
+	other := generateCA(t, cert4now.CommonName("Intermediate CA"), cert4now.Authority(root))
+	expired, err := cert4now.Generate(
+		cert4now.Authority(ca),
+		cert4now.Names("www.example.com"),
+		cert4now.NotBefore(time.Now().AddDate(0, -2, 0)),
+		cert4now.AddDate(0, 1, 0),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := cert4now.Generate(
+		cert4now.Authority(ca),
+		cert4now.Names("www.example.com"),
+		cert4now.ExtKeyUsage(x509.ExtKeyUsageClientAuth),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		Name    string
+		Cert    tls.Certificate
+		Options []cert4now.VerifyOption
+		Want    cert4now.ProblemKind
+		Index   int
+	}{
+		{"hostname", leaf, []cert4now.VerifyOption{cert4now.VerifyName("www.example.org")}, cert4now.ProblemHostname, 0},
+		{"missing intermediate", tls.Certificate{Certificate: leaf.Certificate[:1]}, nil, cert4now.ProblemMissingIntermediate, 0},
+		{"key id mismatch", tls.Certificate{Certificate: [][]byte{leaf.Certificate[0], other.Certificate[0]}}, nil, cert4now.ProblemKeyIDMismatch, 0},
+		{"expired", expired, nil, cert4now.ProblemExpired, 0},
+		{"ext key usage", client, nil, cert4now.ProblemExtKeyUsage, 0},
+		{"unknown authority", ca, []cert4now.VerifyOption{cert4now.VerifyExtKeyUsage(x509.ExtKeyUsageAny)}, cert4now.ProblemUnknownAuthority, 1},
+	}
+	for _, c := range cases {
+		roots := []tls.Certificate{root}
+		if c.Want == cert4now.ProblemUnknownAuthority {
+			roots = []tls.Certificate{other}
+		}
+		_, err := cert4now.Verify(c.Cert, roots, c.Options...)
+		var verr *cert4now.VerifyError
+		if !errors.As(err, &verr) {
+			t.Errorf("%s: want VerifyError, got %v", c.Name, err)
+			continue
+		}
+		if !verr.Has(c.Want) {
+			t.Errorf("%s: want %q, got %v", c.Name, c.Want, verr)
+			continue
+		}
+		for _, p := range verr.Problems {
+			if p.Kind == c.Want && p.Index != c.Index {
+				t.Errorf("%s: want index %d, got %v", c.Name, c.Index, p)
+			}
+		}
+	}
+}
@@ -2,14 +2,98 @@ package cert4now
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"io"
 	"io/fs"
 	"os"
 )
 
+// WriteOption represents an option for writing a private key or a public key.
+type WriteOption func(*writeParam)
+
+type writeParam struct {
+	format   keyFormat
+	der      bool
+	password []byte
+}
+
+type keyFormat int
+
+const (
+	formatPKCS8 keyFormat = iota
+	formatPKCS1
+	formatSEC1
+)
+
+// ErrUnsupportedKeyFormat represents the key can not be encoded in the requested format.
+var ErrUnsupportedKeyFormat = errors.New("key type is not supported by the format")
+
+// PKCS8 returns an option of writing the private key in PKCS#8, or the public key in SubjectPublicKeyInfo.
+// This is the default.
+func PKCS8() WriteOption {
+	return func(p *writeParam) {
+		p.format = formatPKCS8
+	}
+}
+
+// PKCS1 returns an option of writing the RSA key in PKCS#1, that is "RSA PRIVATE KEY" or "RSA PUBLIC KEY".
+func PKCS1() WriteOption {
+	return func(p *writeParam) {
+		p.format = formatPKCS1
+	}
+}
+
+// SEC1 returns an option of writing the ECDSA private key in SEC 1, that is "EC PRIVATE KEY".
+func SEC1() WriteOption {
+	return func(p *writeParam) {
+		p.format = formatSEC1
+	}
+}
+
+// DER returns an option of writing the key in DER instead of PEM.
+func DER() WriteOption {
+	return func(p *writeParam) {
+		p.der = true
+	}
+}
+
+// PEM returns an option of writing the key in PEM. This is the default.
+func PEM() WriteOption {
+	return func(p *writeParam) {
+		p.der = false
+	}
+}
+
+// Encrypt returns an option of encrypting the private key in PKCS#8 with password,
+// that is "ENCRYPTED PRIVATE KEY" using PBES2 with PBKDF2-HMAC-SHA256 and AES-256-CBC.
+func Encrypt(password []byte) WriteOption {
+	return func(p *writeParam) {
+		p.password = password
+	}
+}
+
+func newWriteParam(options []WriteOption) *writeParam {
+	p := &writeParam{}
+	for _, option := range options {
+		option(p)
+	}
+	return p
+}
+
+func (p *writeParam) write(w io.Writer, block *pem.Block) error {
+	if p.der {
+		_, err := w.Write(block.Bytes)
+		return err
+	}
+	return pem.Encode(w, block)
+}
+
 // WriteCertificate writes the certificate into w in PEM format.
 func WriteCertificate(w io.Writer, cert tls.Certificate) error {
 	return pem.Encode(w, &pem.Block{
@@ -18,6 +102,20 @@ func WriteCertificate(w io.Writer, cert tls.Certificate) error {
 	})
 }
 
+// WriteCertificateChain writes the certificate followed by its chain into w in PEM format.
+func WriteCertificateChain(w io.Writer, cert tls.Certificate) error {
+	for _, der := range cert.Certificate {
+		err := pem.Encode(w, &pem.Block{
+			Type:  "CERTIFICATE",
+			Bytes: der,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // WriteCertificateFile writes the certificate into the file of filename in PEM format.
 func WriteCertificateFile(filename string, cert tls.Certificate, perm fs.FileMode) error {
 	p, err := EncodeCertificateToPEM(cert)
@@ -34,30 +132,184 @@ func EncodeCertificateToPEM(cert tls.Certificate) ([]byte, error) {
 	return buf.Bytes(), err
 }
 
-// WritePrivateKey writes the private key into w in PEM format.
-func WritePrivateKey(w io.Writer, cert tls.Certificate) error {
-	der, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+// WritePrivateKey writes the private key into w.
+// The key is written in PKCS#8 and PEM format unless options tell otherwise.
+func WritePrivateKey(w io.Writer, cert tls.Certificate, options ...WriteOption) error {
+	p := newWriteParam(options)
+	block, err := marshalPrivateKey(cert.PrivateKey, p.format)
 	if err != nil {
 		return err
 	}
-	return pem.Encode(w, &pem.Block{
-		Type:  "PRIVATE KEY",
-		Bytes: der,
-	})
+	if p.password != nil {
+		if p.format != formatPKCS8 {
+			return ErrUnsupportedKeyFormat
+		}
+		block.Type = "ENCRYPTED PRIVATE KEY"
+		block.Bytes, err = encryptPKCS8(block.Bytes, p.password)
+		if err != nil {
+			return err
+		}
+	}
+	return p.write(w, block)
+}
+
+// WritePrivateKeyFile writes the private key into the file of filename.
+// The key is written in PKCS#8 and PEM format unless options tell otherwise.
+func WritePrivateKeyFile(filename string, cert tls.Certificate, perm fs.FileMode, options ...WriteOption) error {
+	p, err := EncodePrivateKey(cert, options...)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, p, perm)
+}
+
+// EncodePrivateKey encodes the private key of cert.
+// The key is encoded in PKCS#8 and PEM format unless options tell otherwise.
+func EncodePrivateKey(cert tls.Certificate, options ...WriteOption) ([]byte, error) {
+	var buf bytes.Buffer
+	err := WritePrivateKey(&buf, cert, options...)
+	return buf.Bytes(), err
 }
 
-// WritePrivateKeyFile writes the private key into the file of filename in PEM format.
-func WritePrivateKeyFile(filename string, cert tls.Certificate, perm fs.FileMode) error {
-	p, err := EncodePrivateKeyToPEM(cert)
+// EncodePrivateKeyToPEM encodes the private key of cert into PEM format, even if options has DER.
+func EncodePrivateKeyToPEM(cert tls.Certificate, options ...WriteOption) ([]byte, error) {
+	return EncodePrivateKey(cert, append(append([]WriteOption{}, options...), PEM())...)
+}
+
+func marshalPrivateKey(key crypto.PrivateKey, format keyFormat) (*pem.Block, error) {
+	switch format {
+	case formatPKCS1:
+		k, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, ErrUnsupportedKeyFormat
+		}
+		return &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}, nil
+
+	case formatSEC1:
+		k, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, ErrUnsupportedKeyFormat
+		}
+		der, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, err
+		}
+		return &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}, nil
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &pem.Block{Type: "PRIVATE KEY", Bytes: der}, nil
+}
+
+// WritePublicKey writes the public key of the private key into w.
+// The key is written in SubjectPublicKeyInfo and PEM format, that is "PUBLIC KEY", unless options tell otherwise.
+func WritePublicKey(w io.Writer, cert tls.Certificate, options ...WriteOption) error {
+	p := newWriteParam(options)
+	pub, err := publicKeyOf(cert)
+	if err != nil {
+		return err
+	}
+	block, err := marshalPublicKey(pub, p.format)
+	if err != nil {
+		return err
+	}
+	return p.write(w, block)
+}
+
+// WritePublicKeyFile writes the public key into the file of filename.
+func WritePublicKeyFile(filename string, cert tls.Certificate, perm fs.FileMode, options ...WriteOption) error {
+	p, err := EncodePublicKey(cert, options...)
 	if err != nil {
 		return err
 	}
 	return os.WriteFile(filename, p, perm)
 }
 
-// EncodePrivateKeyToPEM encodes the private key of cert into PEM format.
-func EncodePrivateKeyToPEM(cert tls.Certificate) ([]byte, error) {
+// EncodePublicKey encodes the public key of cert.
+// The key is encoded in SubjectPublicKeyInfo and PEM format unless options tell otherwise.
+func EncodePublicKey(cert tls.Certificate, options ...WriteOption) ([]byte, error) {
 	var buf bytes.Buffer
-	err := WritePrivateKey(&buf, cert)
+	err := WritePublicKey(&buf, cert, options...)
 	return buf.Bytes(), err
 }
+
+// EncodePublicKeyToPEM encodes the public key of cert into PEM format, even if options has DER.
+func EncodePublicKeyToPEM(cert tls.Certificate, options ...WriteOption) ([]byte, error) {
+	return EncodePublicKey(cert, append(append([]WriteOption{}, options...), PEM())...)
+}
+
+func marshalPublicKey(pub crypto.PublicKey, format keyFormat) (*pem.Block, error) {
+	switch format {
+	case formatPKCS1:
+		k, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return nil, ErrUnsupportedKeyFormat
+		}
+		return &pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(k)}, nil
+
+	case formatSEC1:
+		return nil, ErrUnsupportedKeyFormat
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return &pem.Block{Type: "PUBLIC KEY", Bytes: der}, nil
+}
+
+// WriteAuthorizedKey writes the public key into w in the format of OpenSSH authorized_keys.
+func WriteAuthorizedKey(w io.Writer, cert tls.Certificate) error {
+	p, err := EncodeAuthorizedKey(cert)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(p)
+	return err
+}
+
+// WriteAuthorizedKeyFile writes the public key into the file of filename in the format of OpenSSH authorized_keys.
+func WriteAuthorizedKeyFile(filename string, cert tls.Certificate, perm fs.FileMode) error {
+	p, err := EncodeAuthorizedKey(cert)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, p, perm)
+}
+
+// EncodeAuthorizedKey encodes the public key of cert in the format of OpenSSH authorized_keys.
+// The comment is the common name of the certificate if any.
+func EncodeAuthorizedKey(cert tls.Certificate) ([]byte, error) {
+	pub, err := publicKeyOf(cert)
+	if err != nil {
+		return nil, err
+	}
+	line, err := marshalAuthorizedKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	if len(cert.Certificate) > 0 {
+		if c, err := x509.ParseCertificate(cert.Certificate[0]); err == nil && c.Subject.CommonName != "" {
+			line = append(line, ' ')
+			line = append(line, c.Subject.CommonName...)
+		}
+	}
+	return append(line, '\n'), nil
+}
+
+func publicKeyOf(cert tls.Certificate) (crypto.PublicKey, error) {
+	if signer, ok := cert.PrivateKey.(crypto.Signer); ok {
+		return signer.Public(), nil
+	}
+	if len(cert.Certificate) > 0 {
+		c, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return nil, err
+		}
+		return c.PublicKey, nil
+	}
+	return nil, errors.New("no public key")
+}
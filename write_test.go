@@ -2,8 +2,13 @@ package cert4now_test
 
 import (
 	"bytes"
+	"crypto/elliptic"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -32,7 +37,7 @@ func TestWrite(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if diff := cmp.Diff(cert, load); diff != "" {
+	if diff := cmp.Diff(cert, load, ignoreLeaf); diff != "" {
 		t.Fatalf("-want +got\n%s", diff)
 	}
 }
@@ -57,7 +62,136 @@ func TestWriteFile(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if diff := cmp.Diff(cert, load); diff != "" {
+	if diff := cmp.Diff(cert, load, ignoreLeaf); diff != "" {
 		t.Fatalf("-want +got\n%s", diff)
 	}
 }
+
+func TestWritePrivateKey_format(t *testing.T) {
+	rsaCert, err := cert4now.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecCert, err := cert4now.Generate(cert4now.ECDSA(elliptic.P256()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		Cert    tls.Certificate
+		Options []cert4now.WriteOption
+		Type    string
+		Parse   func([]byte) (interface{}, error)
+	}{
+		{rsaCert, nil, "PRIVATE KEY", x509.ParsePKCS8PrivateKey},
+		{rsaCert, []cert4now.WriteOption{cert4now.PKCS1()}, "RSA PRIVATE KEY", func(p []byte) (interface{}, error) {
+			return x509.ParsePKCS1PrivateKey(p)
+		}},
+		{ecCert, []cert4now.WriteOption{cert4now.SEC1()}, "EC PRIVATE KEY", func(p []byte) (interface{}, error) {
+			return x509.ParseECPrivateKey(p)
+		}},
+	}
+	for i, c := range cases {
+		p, err := cert4now.EncodePrivateKeyToPEM(c.Cert, c.Options...)
+		if err != nil {
+			t.Fatalf("[%d] %v", i, err)
+		}
+		block, _ := pem.Decode(p)
+		if block == nil || block.Type != c.Type {
+			t.Fatalf("[%d] want %q, got %v", i, c.Type, block)
+		}
+		key, err := c.Parse(block.Bytes)
+		if err != nil {
+			t.Fatalf("[%d] %v", i, err)
+		}
+		if diff := cmp.Diff(c.Cert.PrivateKey, key); diff != "" {
+			t.Fatalf("[%d] -want +got\n%s", i, diff)
+		}
+
+		der, err := cert4now.EncodePrivateKey(c.Cert, append(c.Options, cert4now.DER())...)
+		if err != nil {
+			t.Fatalf("[%d] %v", i, err)
+		}
+		if !bytes.Equal(block.Bytes, der) {
+			t.Fatalf("[%d] DER differs from PEM", i)
+		}
+
+		again, err := cert4now.EncodePrivateKeyToPEM(c.Cert, append(c.Options, cert4now.DER())...)
+		if err != nil {
+			t.Fatalf("[%d] %v", i, err)
+		}
+		if block, _ := pem.Decode(again); block == nil || !bytes.Equal(block.Bytes, der) {
+			t.Fatalf("[%d] EncodePrivateKeyToPEM is not in PEM", i)
+		}
+	}
+
+	if _, err := cert4now.EncodePrivateKeyToPEM(ecCert, cert4now.PKCS1()); err != cert4now.ErrUnsupportedKeyFormat {
+		t.Fatalf("want ErrUnsupportedKeyFormat, got %v", err)
+	}
+}
+
+func TestWritePublicKey(t *testing.T) {
+	cert, err := cert4now.Generate(cert4now.ECDSA(elliptic.P384()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := cert4now.EncodePublicKeyToPEM(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(p)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		t.Fatalf("unexpected block %v", block)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(parseLeaf(t, cert).PublicKey, pub); diff != "" {
+		t.Fatalf("-want +got\n%s", diff)
+	}
+
+	der, err := cert4now.EncodePublicKey(cert, cert4now.DER())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(block.Bytes, der) {
+		t.Fatal("DER differs from PEM")
+	}
+
+	// The options of the caller are kept as they are, even with the spare capacity.
+	options := make([]cert4now.WriteOption, 1, 2)
+	options[0] = cert4now.DER()
+	if _, err := cert4now.EncodePublicKeyToPEM(cert, options...); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cert4now.EncodePrivateKeyToPEM(cert, options...); err != nil {
+		t.Fatal(err)
+	}
+	if options[:2][1] != nil {
+		t.Fatal("options of the caller are overwritten")
+	}
+}
+
+func TestEncodeAuthorizedKey(t *testing.T) {
+	cert, err := cert4now.Generate(cert4now.CommonName("alice"), cert4now.ECDSA(elliptic.P256()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := cert4now.EncodeAuthorizedKey(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := strings.Fields(string(p))
+	if len(f) != 3 || f[0] != "ecdsa-sha2-nistp256" || f[2] != "alice" {
+		t.Fatalf("unexpected authorized key %q", p)
+	}
+	wire, err := base64.StdEncoding.DecodeString(f[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// string "ecdsa-sha2-nistp256", string "nistp256", string Q (65 bytes)
+	if want := 4 + 19 + 4 + 8 + 4 + 65; len(wire) != want {
+		t.Fatalf("want %d bytes, got %d", want, len(wire))
+	}
+}